@@ -3,29 +3,59 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
-	"strconv"
-	"strings"
 	"sync"
+	"time"
 
 	"groupie/src/go/config"
-	"groupie/src/go/geo"
 	"groupie/src/go/models"
 )
 
 type Client struct {
-	HTTPClient *http.Client
+	HTTPClient       *http.Client
+	BaseURL          string
+	Retry            RetryPolicy
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	breakersMu sync.Mutex
+	breakers   map[string]*CircuitBreaker
+
+	limitersMu sync.RWMutex
+	limiters   map[string]*RateLimiter
+
+	validatorsMu sync.RWMutex
+	validators   map[string]cachedResponse
+}
+
+// cachedResponse garde les validateurs HTTP et le dernier corps reçu pour une URL
+type cachedResponse struct {
+	ETag         string
+	LastModified string
+	Body         []byte
 }
 
 func NewClient(httpClient *http.Client) *Client {
 	return &Client{
 		HTTPClient: httpClient,
+		BaseURL:    config.GetAPIURL(),
+		Retry: RetryPolicy{
+			MaxRetries: config.APIMaxRetries,
+			BaseDelay:  config.APIRetryBaseDelay,
+			MaxDelay:   config.APIRetryMaxDelay,
+		},
+		BreakerThreshold: config.APIBreakerThreshold,
+		BreakerCooldown:  config.APIBreakerCooldown,
+		breakers:         make(map[string]*CircuitBreaker),
+		limiters:         make(map[string]*RateLimiter),
+		validators:       make(map[string]cachedResponse),
 	}
 }
 
+// FetchJSON décode la réponse JSON d'une URL. Les requêtes sont conditionnelles
+// (If-None-Match / If-Modified-Since) : sur un 304, le dernier corps reçu est réutilisé.
 func (c *Client) FetchJSON(ctx context.Context, url string, target interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -33,22 +63,50 @@ func (c *Client) FetchJSON(ctx context.Context, url string, target interface{})
 	}
 	req.Header.Set("User-Agent", config.UserAgentValue)
 
-	resp, err := c.HTTPClient.Do(req)
+	c.validatorsMu.RLock()
+	cached, hasCache := c.validators[url]
+	c.validatorsMu.RUnlock()
+	if hasCache {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := c.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && hasCache {
+		return json.Unmarshal(cached.Body, target)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("appel %s: statut %d", url, resp.StatusCode)
 	}
 
-	return json.NewDecoder(resp.Body).Decode(target)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag != "" || lastModified != "" {
+		c.validatorsMu.Lock()
+		c.validators[url] = cachedResponse{ETag: etag, LastModified: lastModified, Body: body}
+		c.validatorsMu.Unlock()
+	}
+
+	return json.Unmarshal(body, target)
 }
 
 func (c *Client) FetchIndex(ctx context.Context) (*models.APIIndex, error) {
 	var index models.APIIndex
-	if err := c.FetchJSON(ctx, config.BaseAPIURL, &index); err != nil {
+	if err := c.FetchJSON(ctx, c.BaseURL, &index); err != nil {
 		return nil, fmt.Errorf("index api: %w", err)
 	}
 	return &index, nil
@@ -70,99 +128,24 @@ func (c *Client) FetchRelations(ctx context.Context, relationsURL string) (*mode
 	return &relation, nil
 }
 
-type GeocodeService struct {
-	Client  *Client
-	Cache   map[string]models.Coordinates
-	CacheMu sync.RWMutex
-}
-
-func NewGeocodeService(client *Client) *GeocodeService {
-	return &GeocodeService{
-		Client: client,
-		Cache:  make(map[string]models.Coordinates),
-	}
-}
-
-func (g *GeocodeService) LookupCoordinates(ctx context.Context, location string) (*models.Coordinates, error) {
-	key := strings.ToLower(location)
-
-	// 1. Vérifier le cache en mémoire
-	g.CacheMu.RLock()
-	if coords, ok := g.Cache[key]; ok {
-		g.CacheMu.RUnlock()
-		return &coords, nil
+// FetchLocations charge l'index complet des lieux (/api/locations)
+func (c *Client) FetchLocations(ctx context.Context, locationsURL string) ([]models.APILocation, error) {
+	var payload struct {
+		Index []models.APILocation `json:"index"`
 	}
-	g.CacheMu.RUnlock()
-
-	// 2. Vérifier le cache de lieux prédéfinis (instantané)
-	if coords, ok := geo.GetVenueCoordinates(location); ok {
-		g.CacheMu.Lock()
-		g.Cache[key] = *coords
-		g.CacheMu.Unlock()
-		return coords, nil
-	}
-
-	// 3. Fallback: API Nominatim (lent)
-	coords, err := g.geocode(ctx, location)
-	if err != nil {
-		return nil, err
+	if err := c.FetchJSON(ctx, locationsURL, &payload); err != nil {
+		return nil, fmt.Errorf("lieux: %w", err)
 	}
-
-	g.CacheMu.Lock()
-	g.Cache[key] = *coords
-	g.CacheMu.Unlock()
-
-	return coords, nil
+	return payload.Index, nil
 }
 
-func (g *GeocodeService) geocode(ctx context.Context, location string) (*models.Coordinates, error) {
-	baseURL := "https://nominatim.openstreetmap.org/search"
-	params := url.Values{}
-	params.Set("format", "json")
-	params.Set("limit", "1")
-	params.Set("q", location)
-	params.Set("addressdetails", "1")
-
-	query := fmt.Sprintf("%s?%s", baseURL, params.Encode())
-
-	var results []struct {
-		Lat string `json:"lat"`
-		Lon string `json:"lon"`
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, query, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("User-Agent", "GroupieTracker/1.0 (Educational Project)")
-
-	resp, err := g.Client.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("geocoding failed: status %d", resp.StatusCode)
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
-		return nil, err
-	}
-
-	if len(results) == 0 {
-		return nil, errors.New("aucune coordonnée")
+// FetchDates charge l'index complet des dates (/api/dates)
+func (c *Client) FetchDates(ctx context.Context, datesURL string) ([]models.APIDate, error) {
+	var payload struct {
+		Index []models.APIDate `json:"index"`
 	}
-
-	lat, err := strconv.ParseFloat(results[0].Lat, 64)
-	if err != nil {
-		return nil, err
-	}
-
-	lon, err := strconv.ParseFloat(results[0].Lon, 64)
-	if err != nil {
-		return nil, err
+	if err := c.FetchJSON(ctx, datesURL, &payload); err != nil {
+		return nil, fmt.Errorf("dates: %w", err)
 	}
-
-	return &models.Coordinates{Latitude: lat, Longitude: lon}, nil
+	return payload.Index, nil
 }
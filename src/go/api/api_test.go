@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFetchJSONConditional vérifie les requêtes conditionnelles : les validateurs reçus sont
+// renvoyés et un 304 réutilise le dernier corps
+func TestFetchJSONConditional(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string // validateur envoyé par le serveur
+		value     string
+		condition string // en-tête conditionnel attendu au second appel
+	}{
+		{"ETag", "ETag", `"v1"`, "If-None-Match"},
+		{"Last-Modified", "Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT", "If-Modified-Since"},
+		{"sans validateur", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls, notModified int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if tt.condition != "" && r.Header.Get(tt.condition) == tt.value {
+					notModified++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if tt.header != "" {
+					w.Header().Set(tt.header, tt.value)
+				}
+				w.Write([]byte(`{"artists":"/artists"}`))
+			}))
+			defer srv.Close()
+
+			client := NewClient(srv.Client())
+			for i := range 2 {
+				var got struct{ Artists string }
+				if err := client.FetchJSON(context.Background(), srv.URL, &got); err != nil {
+					t.Fatalf("appel %d: %v", i, err)
+				}
+				if got.Artists != "/artists" {
+					t.Errorf("appel %d: corps décodé %+v", i, got)
+				}
+			}
+			want := 0
+			if tt.condition != "" {
+				want = 1
+			}
+			if calls != 2 || notModified != want {
+				t.Errorf("%d appels dont %d en 304, attendu 2 dont %d", calls, notModified, want)
+			}
+		})
+	}
+}
+
+// TestFetchJSONNotModifiedWithoutCache vérifie qu'un 304 sans corps en cache est une erreur
+func TestFetchJSONNotModifiedWithoutCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	var got map[string]string
+	if err := NewClient(srv.Client()).FetchJSON(context.Background(), srv.URL, &got); err == nil {
+		t.Error("FetchJSON sur un 304 sans cache: erreur attendue")
+	}
+}
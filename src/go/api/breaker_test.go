@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerTransitions(t *testing.T) {
+	const cooldown = 20 * time.Millisecond
+	// Opérations : allow (appel accepté), deny (ErrCircuitOpen), ok, fail, release, wait (refroidissement)
+	tests := []struct {
+		name  string
+		steps []string
+		state string
+	}{
+		{"fermé par défaut", []string{"allow"}, BreakerClosed},
+		{"sous le seuil", []string{"fail", "fail", "allow"}, BreakerClosed},
+		{"un succès remet le compteur à zéro", []string{"fail", "fail", "ok", "fail", "fail", "allow"}, BreakerClosed},
+		{"ouvert au seuil", []string{"fail", "fail", "fail", "deny"}, BreakerOpen},
+		{"semi-ouvert après refroidissement", []string{"fail", "fail", "fail", "wait"}, BreakerHalfOpen},
+		{"un seul appel de test", []string{"fail", "fail", "fail", "wait", "allow", "deny"}, BreakerHalfOpen},
+		{"test réussi : fermé", []string{"fail", "fail", "fail", "wait", "allow", "ok", "allow", "allow"}, BreakerClosed},
+		{"test échoué : rouvert", []string{"fail", "fail", "fail", "wait", "allow", "fail", "deny"}, BreakerOpen},
+		{"test annulé : nouvel essai permis", []string{"fail", "fail", "fail", "wait", "allow", "release", "allow"}, BreakerHalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(3, cooldown)
+			for i, step := range tt.steps {
+				switch step {
+				case "allow", "deny":
+					err := b.Allow()
+					if want := step == "deny"; errors.Is(err, ErrCircuitOpen) != want {
+						t.Fatalf("étape %d (%s): Allow() = %v", i, step, err)
+					}
+				case "ok":
+					b.Success()
+				case "fail":
+					b.Failure()
+				case "release":
+					b.release()
+				case "wait":
+					time.Sleep(cooldown + 10*time.Millisecond)
+				}
+			}
+			if got := b.State(); got != tt.state {
+				t.Errorf("State() = %q, attendu %q", got, tt.state)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/geo"
+	"groupie/src/go/models"
+)
+
+// ErrNotCached signale qu'un lieu ne peut être résolu qu'en appelant un backend en ligne
+var ErrNotCached = errors.New("lieu absent du cache")
+
+// ErrNoCoordinates signale un lieu introuvable (aucun backend ne le connaît, ou échec mis en cache)
+var ErrNoCoordinates = geo.ErrNoCoordinates
+
+// GeocodeStore persiste les résultats de géocodage entre redémarrages et instances (voir db.GeocodeStore)
+type GeocodeStore interface {
+	LoadGeocode(key string) (models.GeocodeEntry, bool, error)
+	SaveGeocode(entry models.GeocodeEntry) error
+}
+
+type GeocodeService struct {
+	// Geocoders sont interrogés dans l'ordre après les caches ; les backends hors ligne
+	// répondent aussi à CachedCoordinates
+	Geocoders []geo.Geocoder
+	// Store est consulté après le cache en mémoire ; nil : cache en mémoire seulement
+	Store   GeocodeStore
+	Cache   map[string]cachedCoordinates
+	CacheMu sync.RWMutex
+	// Limiter est la limite de débit de Nominatim ; nil sans backend Nominatim
+	Limiter *RateLimiter
+
+	// inflight regroupe les recherches simultanées d'une même clé
+	inflightMu sync.Mutex
+	inflight   map[string]*geocodeCall
+}
+
+// geocodeCall est une recherche en cours, partagée par tous ses demandeurs
+type geocodeCall struct {
+	done   chan struct{}
+	coords *models.Coordinates
+	err    error
+}
+
+// cachedCoordinates est une entrée du cache en mémoire, relue en base après config.GeocodeMemoryTTL
+type cachedCoordinates struct {
+	coords   models.Coordinates
+	cachedAt time.Time
+}
+
+func NewGeocodeService(store GeocodeStore, geocoders ...geo.Geocoder) *GeocodeService {
+	g := &GeocodeService{
+		Geocoders: geocoders,
+		Store:     store,
+		Cache:     make(map[string]cachedCoordinates),
+		inflight:  make(map[string]*geocodeCall),
+	}
+	for _, geocoder := range geocoders {
+		if n, ok := geocoder.(*Nominatim); ok && g.Limiter == nil {
+			g.Limiter = n.Limiter
+		}
+	}
+	return g
+}
+
+// RetryAfter estime quand Nominatim acceptera une nouvelle requête
+func (g *GeocodeService) RetryAfter() time.Duration {
+	if g.Limiter == nil {
+		return 0
+	}
+	return g.Limiter.Delay()
+}
+
+// GeocodeKey normalise un lieu en clé de cache
+func GeocodeKey(location string) string {
+	return strings.ToLower(strings.TrimSpace(location))
+}
+
+// LookupCoordinates cherche un lieu dans le cache en mémoire, puis le cache persistant,
+// les backends hors ligne et enfin les backends en ligne. Les échecs récents sont servis depuis le cache (ErrNoCoordinates) ;
+// une RateLimitError signale que Nominatim est saturé. Les demandes simultanées d'un même lieu
+// partagent une seule recherche, qui continue même si le demandeur abandonne.
+func (g *GeocodeService) LookupCoordinates(ctx context.Context, location string) (*models.Coordinates, error) {
+	key := GeocodeKey(location)
+
+	// 1. Vérifier le cache en mémoire
+	if coords, ok := g.memory(key); ok {
+		return coords, nil
+	}
+
+	g.inflightMu.Lock()
+	call, running := g.inflight[key]
+	if !running {
+		call = &geocodeCall{done: make(chan struct{})}
+		g.inflight[key] = call
+		go g.run(ctx, key, location, call)
+	}
+	g.inflightMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+	coords := *call.coords
+	return &coords, nil
+}
+
+// run exécute une recherche partagée avec son propre délai, indépendant du premier demandeur
+func (g *GeocodeService) run(ctx context.Context, key, location string, call *geocodeCall) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.NominatimTimeout)
+	defer cancel()
+
+	call.coords, call.err = g.resolve(ctx, key, location)
+
+	g.inflightMu.Lock()
+	delete(g.inflight, key)
+	g.inflightMu.Unlock()
+	close(call.done)
+}
+
+// CachedCoordinates cherche un lieu comme LookupCoordinates mais sans appel réseau :
+// ErrNotCached indique que seul un backend en ligne pourrait répondre
+func (g *GeocodeService) CachedCoordinates(location string) (*models.Coordinates, error) {
+	key := GeocodeKey(location)
+	if coords, ok := g.memory(key); ok {
+		return coords, nil
+	}
+	return g.cached(key, location)
+}
+
+func (g *GeocodeService) memory(key string) (*models.Coordinates, bool) {
+	g.CacheMu.RLock()
+	cached, ok := g.Cache[key]
+	g.CacheMu.RUnlock()
+	if !ok || time.Since(cached.cachedAt) >= config.GeocodeMemoryTTL {
+		return nil, false
+	}
+	coords := cached.coords
+	return &coords, true
+}
+
+// cached consulte le cache persistant puis les backends hors ligne ; ErrNotCached si
+// seul un backend en ligne pourrait répondre
+func (g *GeocodeService) cached(key, location string) (*models.Coordinates, error) {
+	// 2. Cache persistant
+	entry, found := g.load(key)
+	if found && entry.Coordinates != nil {
+		g.remember(key, *entry.Coordinates)
+		return entry.Coordinates, nil
+	}
+
+	// 3. Backends hors ligne (instantané), avant les échecs en cache : un gazetier
+	// importé depuis peut connaître le lieu
+	for _, geocoder := range g.Geocoders {
+		if !geocoder.Offline() {
+			continue
+		}
+		coords, err := geocoder.Geocode(context.Background(), location)
+		if err == nil {
+			g.save(models.GeocodeEntry{Key: key, Query: location, Coordinates: coords, Source: geocoder.Source()})
+			return coords, nil
+		}
+		if !errors.Is(err, ErrNoCoordinates) {
+			log.Printf("géocodage %s %q: %v", geocoder.Source(), location, err)
+		}
+	}
+
+	if found && time.Since(entry.UpdatedAt) < config.GeocodeNegativeTTL {
+		return nil, fmt.Errorf("%w (échec en cache)", ErrNoCoordinates)
+	}
+	if !g.online() {
+		return nil, ErrNoCoordinates
+	}
+	return nil, ErrNotCached
+}
+
+// online indique si un backend en ligne est configuré
+func (g *GeocodeService) online() bool {
+	for _, geocoder := range g.Geocoders {
+		if !geocoder.Offline() {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GeocodeService) resolve(ctx context.Context, key, location string) (*models.Coordinates, error) {
+	if coords, err := g.cached(key, location); !errors.Is(err, ErrNotCached) {
+		return coords, err
+	}
+
+	// 4. Backends en ligne (lents)
+	var failure error
+	failedSource := ""
+	for _, geocoder := range g.Geocoders {
+		if geocoder.Offline() {
+			continue
+		}
+		coords, err := geocoder.Geocode(ctx, location)
+		if err == nil {
+			g.save(models.GeocodeEntry{Key: key, Query: location, Coordinates: coords, Source: geocoder.Source()})
+			return coords, nil
+		}
+		var status *statusError
+		if errors.Is(err, ErrNoCoordinates) || (errors.As(err, &status) && status.permanent()) {
+			if failure == nil {
+				failure, failedSource = err, geocoder.Source()
+			}
+			continue
+		}
+		// Erreur temporaire : elle l'emporte, l'appelant réessaiera plus tard
+		failure, failedSource = err, ""
+	}
+
+	// Seules les réponses définitives sont mises en cache, pas les erreurs réseau,
+	// les délais dépassés, la limite de débit ni les erreurs temporaires (429, 5xx)
+	if failedSource != "" {
+		g.save(models.GeocodeEntry{Key: key, Query: location, Source: failedSource, Error: failure.Error()})
+	}
+	return nil, failure
+}
+
+// Forget retire une clé du cache en mémoire, après une correction ou une purge
+func (g *GeocodeService) Forget(key string) {
+	g.CacheMu.Lock()
+	delete(g.Cache, key)
+	g.CacheMu.Unlock()
+}
+
+// ForgetAll vide le cache en mémoire
+func (g *GeocodeService) ForgetAll() {
+	g.CacheMu.Lock()
+	g.Cache = make(map[string]cachedCoordinates)
+	g.CacheMu.Unlock()
+}
+
+func (g *GeocodeService) remember(key string, coords models.Coordinates) {
+	g.CacheMu.Lock()
+	g.Cache[key] = cachedCoordinates{coords: coords, cachedAt: time.Now()}
+	g.CacheMu.Unlock()
+}
+
+func (g *GeocodeService) load(key string) (models.GeocodeEntry, bool) {
+	if g.Store == nil {
+		return models.GeocodeEntry{}, false
+	}
+	entry, ok, err := g.Store.LoadGeocode(key)
+	if err != nil {
+		log.Printf("cache géocodage %q: %v", key, err)
+		return models.GeocodeEntry{}, false
+	}
+	return entry, ok
+}
+
+// save garde les coordonnées en mémoire et enregistre l'entrée dans le cache persistant
+func (g *GeocodeService) save(entry models.GeocodeEntry) {
+	if entry.Coordinates != nil {
+		g.remember(entry.Key, *entry.Coordinates)
+	}
+	if g.Store == nil {
+		return
+	}
+	entry.UpdatedAt = time.Now()
+	if err := g.Store.SaveGeocode(entry); err != nil {
+		log.Printf("cache géocodage %q: %v", entry.Key, err)
+	}
+}
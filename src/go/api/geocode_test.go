@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"groupie/src/go/geo"
+	"groupie/src/go/models"
+)
+
+// memoryStore est un cache persistant en mémoire
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]models.GeocodeEntry
+}
+
+func (s *memoryStore) LoadGeocode(key string) (models.GeocodeEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	return e, ok, nil
+}
+
+func (s *memoryStore) SaveGeocode(entry models.GeocodeEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.Key] = entry
+	return nil
+}
+
+// TestCachedCoordinatesOrder vérifie l'ordre de résolution hors ligne : une entrée
+// positive en base l'emporte sur le gazetier, qui l'emporte sur un échec en cache
+func TestCachedCoordinatesOrder(t *testing.T) {
+	gazetteer, err := geo.ParseGazetteer(strings.NewReader(
+		"1\tParis\tParis\t\t48.85\t2.35\tP\tPPLC\tFR\t\t\t\t\t\t2138551\t\t\tEurope/Paris\t2024-01-01\n" +
+			"2\tLyon\tLyon\t\t45.75\t4.85\tP\tPPLA\tFR\t\t\t\t\t\t522228\t\t\tEurope/Paris\t2024-01-01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memoryStore{entries: map[string]models.GeocodeEntry{
+		GeocodeKey("Paris, France"): {Key: GeocodeKey("Paris, France"), Coordinates: &models.Coordinates{Latitude: 1, Longitude: 2}, Source: models.GeoSourceManual},
+		GeocodeKey("Lyon, France"):  {Key: GeocodeKey("Lyon, France"), Source: models.GeoSourceNominatim, Error: "aucune coordonnée", UpdatedAt: time.Now()},
+	}}
+	service := NewGeocodeService(store, gazetteer)
+
+	coords, err := service.CachedCoordinates("Paris, France")
+	if err != nil || coords.Latitude != 1 {
+		t.Errorf("Paris = %v, %v ; attendu l'entrée en base", coords, err)
+	}
+
+	coords, err = service.CachedCoordinates("Lyon, France")
+	if err != nil || coords.Latitude != 45.75 {
+		t.Fatalf("Lyon = %v, %v ; attendu le gazetier", coords, err)
+	}
+	if e := store.entries[GeocodeKey("Lyon, France")]; e.Source != models.GeoSourceGazetteer || e.Coordinates == nil {
+		t.Errorf("entrée enregistrée = %+v, attendu source gazetteer", e)
+	}
+
+	if _, err := service.CachedCoordinates("Nantes, France"); err == nil {
+		t.Error("Nantes résolu sans backend")
+	}
+}
+
+// slowGeocoder est un backend en ligne qui attend release avant de répondre
+type slowGeocoder struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (g *slowGeocoder) Geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	g.calls.Add(1)
+	select {
+	case <-g.release:
+		return &models.Coordinates{Latitude: 48.85, Longitude: 2.35}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (g *slowGeocoder) Source() string { return models.GeoSourceNominatim }
+func (g *slowGeocoder) Offline() bool  { return false }
+
+// TestLookupCoordinatesCoalesces vérifie que les demandes simultanées d'un même lieu
+// partagent une seule recherche, qui continue quand un demandeur abandonne
+func TestLookupCoordinatesCoalesces(t *testing.T) {
+	geocoder := &slowGeocoder{release: make(chan struct{})}
+	service := NewGeocodeService(nil, geocoder)
+
+	// Le premier demandeur abandonne avant la réponse
+	ctx, cancel := context.WithCancel(context.Background())
+	abandoned := make(chan error, 1)
+	go func() {
+		_, err := service.LookupCoordinates(ctx, "Paris, France")
+		abandoned <- err
+	}()
+	for geocoder.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-abandoned; !errors.Is(err, context.Canceled) {
+		t.Errorf("demandeur annulé: err = %v", err)
+	}
+
+	// Même clé malgré la casse et les espaces
+	locations := []string{"Paris, France", "paris, france", "  PARIS, FRANCE "}
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var coords *models.Coordinates
+			coords, errs[i] = service.LookupCoordinates(context.Background(), locations[i%len(locations)])
+			if errs[i] == nil && coords.Latitude != 48.85 {
+				errs[i] = fmt.Errorf("coordonnées %v", coords)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(geocoder.release)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("demande %d: %v", i, err)
+		}
+	}
+	if n := geocoder.calls.Load(); n != 1 {
+		t.Errorf("%d appels au géocodeur, attendu 1", n)
+	}
+	// La réponse est ensuite servie depuis le cache en mémoire
+	if _, err := service.CachedCoordinates("Paris, France"); err != nil {
+		t.Errorf("CachedCoordinates après la recherche: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+// statusError est une réponse HTTP d'erreur de Nominatim
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("geocoding failed: status %d", e.code)
+}
+
+func (e *statusError) permanent() bool {
+	return e.code < 500 && e.code != http.StatusTooManyRequests
+}
+
+// Nominatim est le Geocoder d'un service compatible avec l'API de recherche de Nominatim :
+// le service public ou un bouchon local (voir config.GetNominatimURL)
+type Nominatim struct {
+	Client *Client
+	URL    string
+	// Limiter est la limite de débit de l'hôte, appliquée par Client à chaque requête
+	Limiter *RateLimiter
+}
+
+// NewNominatim enregistre la limite de débit de baseURL auprès de client
+func NewNominatim(client *Client, baseURL string) *Nominatim {
+	limiter := NewRateLimiter(config.NominatimInterval, config.NominatimBurst, config.NominatimMaxWait)
+	if u, err := url.Parse(baseURL); err == nil {
+		client.SetRateLimit(u.Host, limiter)
+	}
+	return &Nominatim{Client: client, URL: baseURL, Limiter: limiter}
+}
+
+func (n *Nominatim) Source() string { return models.GeoSourceNominatim }
+
+func (n *Nominatim) Offline() bool { return false }
+
+// Geocode retourne le premier résultat de Nominatim ; ErrNoCoordinates si la réponse est vide
+func (n *Nominatim) Geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	params := url.Values{}
+	params.Set("format", "json")
+	params.Set("limit", "1")
+	params.Set("q", location)
+	params.Set("addressdetails", "1")
+
+	query := fmt.Sprintf("%s?%s", n.URL, params.Encode())
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "GroupieTracker/1.0 (Educational Project)")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoCoordinates
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	lon, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Coordinates{Latitude: lat, Longitude: lon}, nil
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited est retournée quand un appel dépasserait la limite de débit d'un hôte
+var ErrRateLimited = errors.New("limite de requêtes atteinte")
+
+// RateLimitError précise quand réessayer ; errors.Is(err, ErrRateLimited) est vrai
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%v, réessayer dans %s", ErrRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// RateLimiter est un seau à jetons : un jeton toutes les Interval, Burst au plus en réserve.
+// Les appels sont servis dans l'ordre d'arrivée ; un appel qui devrait attendre plus que
+// MaxWait (ou que l'échéance de son contexte) échoue aussitôt avec une RateLimitError.
+type RateLimiter struct {
+	Interval time.Duration
+	Burst    int
+	MaxWait  time.Duration
+
+	mu sync.Mutex
+	// tat est l'instant théorique où le seau sera de nouveau plein
+	tat time.Time
+}
+
+func NewRateLimiter(interval time.Duration, burst int, maxWait time.Duration) *RateLimiter {
+	return &RateLimiter{
+		Interval: interval,
+		Burst:    max(burst, 1),
+		MaxWait:  maxWait,
+	}
+}
+
+// Wait réserve un jeton et attend qu'il soit disponible
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	now := time.Now()
+	l.mu.Lock()
+	tat := l.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	wait := tat.Sub(now) - time.Duration(l.Burst-1)*l.Interval
+	if wait > 0 {
+		deadline, hasDeadline := ctx.Deadline()
+		if (l.MaxWait > 0 && wait > l.MaxWait) || (hasDeadline && now.Add(wait).After(deadline)) {
+			l.mu.Unlock()
+			return &RateLimitError{RetryAfter: wait}
+		}
+	}
+	l.tat = tat.Add(l.Interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Delay retourne l'attente avant le prochain jeton libre
+func (l *RateLimiter) Delay() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return max(time.Until(l.tat)-time.Duration(l.Burst-1)*l.Interval, 0)
+}
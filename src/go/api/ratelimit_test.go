@@ -0,0 +1,86 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		burst    int
+		maxWait  time.Duration
+		timeout  time.Duration // échéance du contexte, 0 = aucune
+		calls    int
+		refused  int           // appels refusés (RateLimitError), toujours les derniers
+		minTime  time.Duration // durée minimale de l'ensemble des appels
+		maxTime  time.Duration
+	}{
+		{"rafale servie sans attente", 100 * time.Millisecond, 3, 0, 0, 3, 0, 0, 50 * time.Millisecond},
+		{"au-delà de la rafale : attente", 30 * time.Millisecond, 1, 0, 0, 3, 0, 60 * time.Millisecond, 500 * time.Millisecond},
+		{"attente au-delà de MaxWait : refus immédiat", 200 * time.Millisecond, 1, 50 * time.Millisecond, 0, 3, 2, 0, 50 * time.Millisecond},
+		{"attente au-delà de l'échéance : refus immédiat", 200 * time.Millisecond, 1, 0, 50 * time.Millisecond, 2, 1, 0, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.interval, tt.burst, tt.maxWait)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			refused := 0
+			for i := range tt.calls {
+				err := l.Wait(ctx)
+				var limited *RateLimitError
+				switch {
+				case err == nil:
+					if refused > 0 {
+						t.Errorf("appel %d accepté après un refus", i)
+					}
+				case errors.As(err, &limited) && errors.Is(err, ErrRateLimited):
+					refused++
+					if limited.RetryAfter <= 0 || limited.RetryAfter > tt.interval {
+						t.Errorf("appel %d: RetryAfter = %s, attendu dans ]0, %s]", i, limited.RetryAfter, tt.interval)
+					}
+				default:
+					t.Fatalf("appel %d: %v", i, err)
+				}
+			}
+			elapsed := time.Since(start)
+			if refused != tt.refused {
+				t.Errorf("%d appels refusés, attendu %d", refused, tt.refused)
+			}
+			if elapsed < tt.minTime || elapsed > tt.maxTime {
+				t.Errorf("durée %s, attendue entre %s et %s", elapsed, tt.minTime, tt.maxTime)
+			}
+			// Un appel refusé ne réserve pas de jeton
+			if d := l.Delay(); d > tt.interval {
+				t.Errorf("Delay() = %s après les refus, attendu au plus %s", d, tt.interval)
+			}
+		})
+	}
+}
+
+// TestRateLimiterCancel vérifie qu'un appel en attente rend la main à l'annulation du contexte
+func TestRateLimiterCancel(t *testing.T) {
+	l := NewRateLimiter(time.Second, 1, 0)
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if err := l.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, attendu context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("annulation prise en compte après %s", elapsed)
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy décrit les nouvelles tentatives après une erreur réseau ou 5xx
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// Backoff retourne l'attente avant la tentative suivante (exponentielle + jitter)
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << attempt
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	// Jitter: entre 50% et 100% du délai
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// parseRetryAfter lit l'en-tête Retry-After (secondes ou date HTTP)
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+type attemptsKey struct{}
+
+// WithAttemptCount retourne un contexte dans lequel Do compte ses tentatives HTTP dans n
+func WithAttemptCount(ctx context.Context, n *atomic.Int32) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, n)
+}
+
+func isRetryableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// Do envoie la requête en appliquant la politique de retry, la limite de débit et le
+// disjoncteur de l'hôte. Une fois les tentatives épuisées sur une erreur HTTP, la dernière
+// réponse est retournée pour que l'appelant gère le statut. Un Retry-After trop long pour
+// l'échéance du contexte donne une *RateLimitError qui porte l'attente demandée.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	breaker := c.breakerFor(req.URL.Host)
+	limiter := c.limiterFor(req.URL.Host)
+
+	for attempt := 0; ; attempt++ {
+		// Chaque tentative consomme un jeton, nouvelles tentatives comprises
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+			}
+		}
+		if err := breaker.Allow(); err != nil {
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+		}
+
+		if n, ok := ctx.Value(attemptsKey{}).(*atomic.Int32); ok {
+			n.Add(1)
+		}
+		resp, err := c.HTTPClient.Do(req.Clone(ctx))
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				breaker.release()
+				return nil, err
+			}
+			breaker.Failure()
+		case isRetryableStatus(resp.StatusCode):
+			if resp.StatusCode >= 500 {
+				breaker.Failure()
+			} else {
+				breaker.Success()
+			}
+			wait = parseRetryAfter(resp.Header.Get("Retry-After"))
+		default:
+			breaker.Success()
+			return resp, nil
+		}
+
+		delay := c.Retry.Backoff(attempt)
+		if wait > delay {
+			delay = wait
+		}
+		if attempt >= c.Retry.MaxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		// Un Retry-After au-delà de MaxDelay n'est attendu que si l'échéance du contexte le
+		// permet ; sinon l'appelant reçoit une RateLimitError pour replanifier l'appel
+		if delay > c.Retry.MaxDelay {
+			if deadline, ok := ctx.Deadline(); !ok || time.Now().Add(delay).After(deadline) {
+				return nil, fmt.Errorf("%s: %w", req.URL.Host, &RateLimitError{RetryAfter: delay})
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) breakerFor(host string) *CircuitBreaker {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	b, ok := c.breakers[host]
+	if !ok {
+		b = NewCircuitBreaker(c.BreakerThreshold, c.BreakerCooldown)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// SetRateLimit limite le débit des requêtes vers un hôte
+func (c *Client) SetRateLimit(host string, limiter *RateLimiter) {
+	c.limitersMu.Lock()
+	c.limiters[host] = limiter
+	c.limitersMu.Unlock()
+}
+
+func (c *Client) limiterFor(host string) *RateLimiter {
+	c.limitersMu.RLock()
+	defer c.limitersMu.RUnlock()
+	return c.limiters[host]
+}
+
+// BreakerStates retourne l'état du disjoncteur de chaque hôte contacté
+func (c *Client) BreakerStates() map[string]string {
+	c.breakersMu.Lock()
+	defer c.breakersMu.Unlock()
+	states := make(map[string]string, len(c.breakers))
+	for host, b := range c.breakers {
+		states[host] = b.State()
+	}
+	return states
+}
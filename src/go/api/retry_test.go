@@ -0,0 +1,146 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestDoRetryAfter vérifie qu'un Retry-After au-delà de MaxDelay est attendu seulement si
+// l'échéance du contexte le permet, et sinon remonté en *RateLimitError
+func TestDoRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration // 0 = contexte sans échéance
+		status  int
+		calls   int32
+		limited bool
+	}{
+		{"sans échéance", 0, 0, 1, true},
+		{"échéance trop proche", 500 * time.Millisecond, 0, 1, true},
+		{"échéance suffisante", 5 * time.Second, http.StatusOK, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			client := NewClient(srv.Client())
+			client.Retry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			resp, err := client.Do(req)
+
+			var limited *RateLimitError
+			if got := errors.As(err, &limited); got != tt.limited {
+				t.Fatalf("err = %v, RateLimitError attendue : %v", err, tt.limited)
+			}
+			if tt.limited {
+				if limited.RetryAfter != time.Second || !errors.Is(err, ErrRateLimited) {
+					t.Errorf("RetryAfter = %s, attendu 1s", limited.RetryAfter)
+				}
+			} else {
+				resp.Body.Close()
+				if resp.StatusCode != tt.status {
+					t.Errorf("statut = %d, attendu %d", resp.StatusCode, tt.status)
+				}
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("%d requêtes, attendu %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32 // réponses en erreur avant un 200
+		errStatus int
+		threshold int
+		status    int  // statut retourné, 0 si Do échoue
+		open      bool // ErrCircuitOpen attendue
+		calls     int32
+	}{
+		{"succès direct", 0, http.StatusServiceUnavailable, 10, http.StatusOK, false, 1},
+		{"succès après deux 503", 2, http.StatusServiceUnavailable, 10, http.StatusOK, false, 3},
+		{"429 réessayé", 1, http.StatusTooManyRequests, 10, http.StatusOK, false, 2},
+		{"404 non réessayé", 1, http.StatusNotFound, 10, http.StatusNotFound, false, 1},
+		{"tentatives épuisées : dernière réponse", 100, http.StatusInternalServerError, 10, http.StatusInternalServerError, false, 4},
+		{"disjoncteur ouvert", 100, http.StatusInternalServerError, 2, 0, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.errStatus)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer srv.Close()
+
+			client := NewClient(srv.Client())
+			client.Retry = RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+			client.BreakerThreshold = tt.threshold
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			resp, err := client.Do(req)
+			switch {
+			case tt.open:
+				if !errors.Is(err, ErrCircuitOpen) {
+					t.Errorf("err = %v, attendu ErrCircuitOpen", err)
+				}
+			case err != nil:
+				t.Fatalf("Do: %v", err)
+			default:
+				resp.Body.Close()
+				if resp.StatusCode != tt.status {
+					t.Errorf("statut = %d, attendu %d", resp.StatusCode, tt.status)
+				}
+			}
+			if n := calls.Load(); n != tt.calls {
+				t.Errorf("%d requêtes, attendu %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"", 0, 0},
+		{"3", 3 * time.Second, 3 * time.Second},
+		{"-1", 0, 0},
+		{"abc", 0, 0},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %s, attendu entre %s et %s", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"groupie/src/go/models"
+)
+
+// DataSource fournit les données brutes des artistes, quelle que soit leur origine
+type DataSource interface {
+	FetchIndex(ctx context.Context) (*models.APIIndex, error)
+	FetchArtists(ctx context.Context, artistsURL string) ([]models.APIArtist, error)
+	FetchRelations(ctx context.Context, relationsURL string) (*models.APIRelation, error)
+	FetchLocations(ctx context.Context, locationsURL string) ([]models.APILocation, error)
+	FetchDates(ctx context.Context, datesURL string) ([]models.APIDate, error)
+}
+
+// Fichiers attendus dans un dossier de données local (même format que l'API distante)
+const (
+	localArtistsFile   = "artists.json"
+	localRelationsFile = "relation.json"
+	localLocationsFile = "locations.json"
+	localDatesFile     = "dates.json"
+)
+
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// FileSource lit les données depuis un système de fichiers contenant
+// artists.json ([]APIArtist), relation.json ({"index": []APIRelation})
+// et, optionnellement, locations.json et dates.json.
+type FileSource struct {
+	FS fs.FS
+
+	mu        sync.Mutex
+	relations map[int]models.APIRelation
+}
+
+// NewLocalSource crée une source lisant un dossier local
+func NewLocalSource(dir string) *FileSource {
+	return &FileSource{FS: os.DirFS(dir)}
+}
+
+// NewFixtureSource crée une source sur le jeu de données embarqué dans le binaire
+func NewFixtureSource() *FileSource {
+	sub, err := fs.Sub(fixtureFiles, "fixtures")
+	if err != nil {
+		panic(err)
+	}
+	return &FileSource{FS: sub}
+}
+
+func (s *FileSource) readJSON(name string, target interface{}) error {
+	data, err := fs.ReadFile(s.FS, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// FetchIndex retourne les noms des fichiers locaux et invalide le cache des relations
+func (s *FileSource) FetchIndex(ctx context.Context) (*models.APIIndex, error) {
+	s.mu.Lock()
+	s.relations = nil
+	s.mu.Unlock()
+
+	return &models.APIIndex{
+		ArtistsURL:   localArtistsFile,
+		LocationsURL: localLocationsFile,
+		DatesURL:     localDatesFile,
+		RelationURL:  localRelationsFile,
+	}, nil
+}
+
+func (s *FileSource) FetchArtists(ctx context.Context, artistsURL string) ([]models.APIArtist, error) {
+	var artists []models.APIArtist
+	if err := s.readJSON(localArtistsFile, &artists); err != nil {
+		return nil, fmt.Errorf("chargement artistes: %w", err)
+	}
+	return artists, nil
+}
+
+// FetchRelations retrouve la relation d'après l'identifiant en fin d'URL
+// (ex: ".../api/relation/3"), ce qui accepte aussi les URLs de l'API distante.
+func (s *FileSource) FetchRelations(ctx context.Context, relationsURL string) (*models.APIRelation, error) {
+	id, err := strconv.Atoi(path.Base(strings.TrimSuffix(relationsURL, "/")))
+	if err != nil {
+		return nil, fmt.Errorf("relation: url invalide %q", relationsURL)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.relations == nil {
+		var payload struct {
+			Index []models.APIRelation `json:"index"`
+		}
+		if err := s.readJSON(localRelationsFile, &payload); err != nil {
+			return nil, fmt.Errorf("relation: %w", err)
+		}
+		s.relations = make(map[int]models.APIRelation, len(payload.Index))
+		for _, rel := range payload.Index {
+			s.relations[rel.ID] = rel
+		}
+	}
+
+	rel, ok := s.relations[id]
+	if !ok {
+		return nil, fmt.Errorf("relation: artiste %d absent", id)
+	}
+	return &rel, nil
+}
+
+func (s *FileSource) FetchLocations(ctx context.Context, locationsURL string) ([]models.APILocation, error) {
+	var payload struct {
+		Index []models.APILocation `json:"index"`
+	}
+	if err := s.readJSON(localLocationsFile, &payload); err != nil {
+		return nil, fmt.Errorf("lieux: %w", err)
+	}
+	return payload.Index, nil
+}
+
+func (s *FileSource) FetchDates(ctx context.Context, datesURL string) ([]models.APIDate, error) {
+	var payload struct {
+		Index []models.APIDate `json:"index"`
+	}
+	if err := s.readJSON(localDatesFile, &payload); err != nil {
+		return nil, fmt.Errorf("dates: %w", err)
+	}
+	return payload.Index, nil
+}
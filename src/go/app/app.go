@@ -2,9 +2,12 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"groupie/src/go/api"
@@ -12,7 +15,9 @@ import (
 	"groupie/src/go/db"
 	"groupie/src/go/game"
 	"groupie/src/go/geo"
+	"groupie/src/go/images"
 	"groupie/src/go/models"
+	"groupie/src/go/notify"
 	"groupie/src/go/templates"
 )
 
@@ -20,7 +25,11 @@ type AppInitializer struct {
 	App            *models.App
 	APIClient      *api.Client
 	GeocodeService *api.GeocodeService
+	GeocodeQueue   *game.GeocodeQueue
+	Notifier       *notify.Notifier
+	Gazetteer      *geo.Gazetteer
 	DataService    *game.DataService
+	ImageCache     *images.Cache
 	Templates      map[string]*template.Template
 }
 
@@ -39,68 +48,103 @@ func InitializeApp() (*AppInitializer, error) {
 			Timeout: config.ReadTimeout,
 		},
 		Templates: tmplSet,
-		GeoCache:  make(map[string]models.Coordinates),
 	}
 
 	apiClient := api.NewClient(app.Client)
-	geocodeService := api.NewGeocodeService(apiClient)
-	dataService := game.NewDataService(app, apiClient)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-	if err := dataService.RefreshData(ctx); err != nil {
+	gazetteer := loadGazetteer(config.GetGazetteerPath())
+	geocodeService := api.NewGeocodeService(db.GeocodeStore{}, newGeocoders(apiClient, gazetteer)...)
+	source, err := newDataSource(apiClient)
+	if err != nil {
 		return nil, err
 	}
+	dataService := game.NewDataService(app, source, config.GetSnapshotPath(), config.GetOverlayPath())
+	notifier := notify.NewNotifier(db.SearchStore{})
+	dataService.OnRefresh(notifier.Enqueue)
+	geocodeQueue := game.NewGeocodeQueue(dataService, geocodeService)
+	dataService.OnRefresh(geocodeQueue.EnqueueMissing)
+
+	if err := dataService.LoadSnapshot(); err == nil {
+		log.Printf("Snapshot chargé (âge: %s)", dataService.DataAge().Round(time.Second))
+		if err := dataService.ReloadOverlay(); err != nil {
+			log.Printf("overlay: %v", err)
+		}
+		if config.IsOfflineMode() {
+			log.Println("Mode hors ligne: pas de rafraîchissement de l'API")
+		} else {
+			dataService.StartRefresh("startup")
+		}
+	} else {
+		// Les sources locales et le jeu d'essai construisent le premier snapshot sans réseau
+		if config.IsOfflineMode() && config.GetDataSource() == config.DataSourceHTTP {
+			return nil, fmt.Errorf("mode hors ligne sans snapshot utilisable: %w", err)
+		}
+		log.Printf("Snapshot indisponible (%v), chargement depuis la source %s...", err, config.GetDataSource())
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := dataService.RefreshData(ctx); err != nil {
+			return nil, err
+		}
+	}
 
-	// Pré-géocoder TOUS les lieux au démarrage
-	log.Println("Pré-chargement des coordonnées des concerts...")
-	preloadAllCoordinates(dataService, geocodeService)
-	log.Println("Coordonnées pré-chargées avec succès")
+	if interval := config.GetRefreshInterval(); interval > 0 && !config.IsOfflineMode() {
+		log.Printf("Rafraîchissement planifié toutes les %s", interval)
+		dataService.StartScheduler(context.Background(), interval)
+	}
+
+	// Les lieux sans coordonnées sont géocodés en arrière-plan, sans bloquer le démarrage
+	geocodeQueue.EnqueueMissing(dataService.GetArtists())
+	geocodeQueue.Start(context.Background())
 
 	return &AppInitializer{
 		App:            app,
 		APIClient:      apiClient,
 		GeocodeService: geocodeService,
+		GeocodeQueue:   geocodeQueue,
+		Notifier:       notifier,
+		Gazetteer:      gazetteer,
 		DataService:    dataService,
+		ImageCache:     images.NewCache(config.GetImageCacheDir(), apiClient, config.PlaceholderImagePath),
 		Templates:      tmplSet,
 	}, nil
 }
 
-// preloadAllCoordinates charge toutes les coordonnées au démarrage
-func preloadAllCoordinates(dataService *game.DataService, geocodeService *api.GeocodeService) {
-	artists := dataService.GetArtists()
-
-	coordsCount := 0
-	missingCount := 0
-
-	for i := range artists {
-		for j := range artists[i].Concerts {
-			concert := &artists[i].Concerts[j]
-
-			// Si déjà des coordonnées, skip
-			if concert.Coordinates != nil {
-				coordsCount++
-				continue
-			}
-
-			// Essayer le cache de lieux connus
-			if coords, ok := geo.GetVenueCoordinates(concert.DisplayLocation); ok {
-				concert.Coordinates = coords
-				coordsCount++
-				continue
-			}
-
-			if coords, ok := geo.GetVenueCoordinates(concert.Location); ok {
-				concert.Coordinates = coords
-				coordsCount++
-				continue
-			}
+// newDataSource choisit la source des artistes selon la configuration
+func newDataSource(apiClient *api.Client) (api.DataSource, error) {
+	switch source := config.GetDataSource(); source {
+	case config.DataSourceHTTP:
+		return apiClient, nil
+	case config.DataSourceLocal:
+		log.Printf("Source de données locale: %s", config.GetDataDir())
+		return api.NewLocalSource(config.GetDataDir()), nil
+	case config.DataSourceFixture:
+		log.Println("Source de données: jeu d'essai embarqué")
+		return api.NewFixtureSource(), nil
+	default:
+		return nil, fmt.Errorf("source de données inconnue: %q", source)
+	}
+}
 
-			// Ne PAS appeler l'API au démarrage - trop lent
-			// Les lieux sans coordonnées ne seront pas affichés sur la carte
-			missingCount++
+// loadGazetteer charge le gazetier hors ligne ; vide si le fichier est absent ou invalide
+func loadGazetteer(path string) *geo.Gazetteer {
+	gazetteer, err := geo.LoadGazetteer(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("gazetier %s: %v", path, err)
 		}
+		return geo.NewGazetteer()
 	}
+	log.Printf("Gazetier chargé: %d villes (%s)", gazetteer.Len(), path)
+	return gazetteer
+}
 
-	log.Printf("Coordonnées: %d trouvées, %d manquantes", coordsCount, missingCount)
+// newGeocoders ordonne les backends de géocodage : lieux prédéfinis, gazetier, puis
+// Nominatim s'il est configuré
+func newGeocoders(apiClient *api.Client, gazetteer *geo.Gazetteer) []geo.Geocoder {
+	geocoders := []geo.Geocoder{geo.VenueTable{}, gazetteer}
+	if url := config.GetNominatimURL(); url != "" {
+		geocoders = append(geocoders, api.NewNominatim(apiClient, url))
+	} else {
+		log.Println("Nominatim désactivé: géocodage hors ligne uniquement")
+	}
+	return geocoders
 }
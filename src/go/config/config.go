@@ -6,9 +6,90 @@ import (
 )
 
 const (
-	BaseAPIURL    = "https://groupietrackers.herokuapp.com/api"
-	ReadTimeout   = 10 * time.Second
+	BaseAPIURL     = "https://groupietrackers.herokuapp.com/api"
+	ReadTimeout    = 10 * time.Second
 	UserAgentValue = "GroupieTrackerStudent/1.0 (ynov project)"
+
+	// SnapshotVersion est incrémenté à chaque changement du format du fichier de snapshot
+	SnapshotVersion     = 2
+	DefaultSnapshotPath = "data/snapshot.json"
+	// SnapshotSaveDelay regroupe les sauvegardes déclenchées par les coordonnées géocodées
+	SnapshotSaveDelay = 5 * time.Second
+
+	// Sources de données disponibles pour les artistes
+	DataSourceHTTP    = "http"
+	DataSourceLocal   = "local"
+	DataSourceFixture = "fixture"
+	DefaultDataDir    = "data/api"
+
+	DefaultOverlayPath = "data/overlay.json"
+
+	DefaultImageCacheDir = "data/images"
+	PlaceholderImagePath = "assets/pictures/cover.png"
+
+	// Gazetier hors ligne (villes au format GeoNames), importable via /api/admin/gazetteer
+	DefaultGazetteerPath = "data/gazetteer.tsv"
+	MaxGazetteerBytes    = 64 << 20
+
+	// RefreshWorkers limite le nombre de relations chargées en parallèle
+	RefreshWorkers = 8
+
+	// Retry et disjoncteur des appels HTTP sortants
+	APIMaxRetries       = 3
+	APIRetryBaseDelay   = 300 * time.Millisecond
+	APIRetryMaxDelay    = 5 * time.Second
+	APIBreakerThreshold = 5
+	APIBreakerCooldown  = 30 * time.Second
+
+	// MaxChangeHistory limite le nombre de différences gardées en mémoire
+	MaxChangeHistory = 200
+
+	// RefreshTimeout borne la durée d'un rafraîchissement en arrière-plan
+	RefreshTimeout = 2 * time.Minute
+	// MaxRefreshJobs limite le nombre de jobs de rafraîchissement gardés en mémoire
+	MaxRefreshJobs = 50
+	// NotifyTimeout borne l'évaluation des recherches enregistrées après un rafraîchissement
+	NotifyTimeout = 2 * time.Minute
+
+	// SearchLimitPerType limite le nombre de résultats par type dans /api/search
+	SearchLimitPerType = 10
+
+	// Pagination de /api/artists et /api/filter
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+
+	// Rayon du filtre géographique de /api/filter, en km
+	DefaultRadiusKm = 100
+	MaxRadiusKm     = 20000
+
+	// Limites du filtre JSON de POST /api/filter
+	MaxFilterBodyBytes = 64 << 10
+	MaxFilterNodes     = 200
+	MaxFilterDepth     = 16
+
+	// Cache de géocodage : un échec est retenté après GeocodeNegativeTTL ; le cache en mémoire
+	// est relu en base après GeocodeMemoryTTL (corrections faites sur une autre instance)
+	GeocodeNegativeTTL = 24 * time.Hour
+	GeocodeMemoryTTL   = 10 * time.Minute
+
+	// Nominatim : une requête par seconde au plus (politique d'usage), attente maximale
+	// d'un appel avant une erreur 429, et durée d'une recherche partagée
+	NominatimURL      = "https://nominatim.openstreetmap.org/search"
+	NominatimInterval = time.Second
+	NominatimBurst    = 1
+	NominatimMaxWait  = 3 * time.Second
+	NominatimTimeout  = 10 * time.Second
+
+	// Géocodage en arrière-plan : une requête Nominatim toutes les GeocodeQueueInterval au plus
+	// (le reste du débit est laissé aux pages), résultats publiés par lots
+	GeocodeQueueInterval      = 2 * time.Second
+	GeocodeQueueBatch         = 20
+	GeocodeQueueFlushInterval = 30 * time.Second
+
+	// Recherches enregistrées et notifications
+	MaxSavedSearches   = 50
+	MaxSavedSearchName = 100
+	NotificationsLimit = 100
 )
 
 func GetPort() string {
@@ -19,3 +100,99 @@ func GetPort() string {
 	return port
 }
 
+// GetSnapshotPath retourne le chemin du snapshot local des artistes
+func GetSnapshotPath() string {
+	path := os.Getenv("SNAPSHOT_PATH")
+	if path == "" {
+		return DefaultSnapshotPath
+	}
+	return path
+}
+
+// IsOfflineMode indique si le serveur doit démarrer sans contacter l'API distante
+func IsOfflineMode() bool {
+	value := os.Getenv("OFFLINE_MODE")
+	return value == "1" || value == "true"
+}
+
+// GetAPIURL retourne l'URL de base de l'API des artistes
+func GetAPIURL() string {
+	url := os.Getenv("API_URL")
+	if url == "" {
+		return BaseAPIURL
+	}
+	return url
+}
+
+// GetDataSource retourne la source de données configurée (http, local ou fixture)
+func GetDataSource() string {
+	source := os.Getenv("DATA_SOURCE")
+	if source == "" {
+		return DataSourceHTTP
+	}
+	return source
+}
+
+// GetDataDir retourne le dossier lu par la source de données locale
+func GetDataDir() string {
+	dir := os.Getenv("DATA_DIR")
+	if dir == "" {
+		return DefaultDataDir
+	}
+	return dir
+}
+
+// GetRefreshInterval retourne l'intervalle du rafraîchissement planifié (0 = désactivé)
+func GetRefreshInterval() time.Duration {
+	value := os.Getenv("REFRESH_INTERVAL")
+	if value == "" {
+		return 0
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < 0 {
+		return 0
+	}
+	return interval
+}
+
+// GetOverlayPath retourne le chemin du fichier d'overlay des métadonnées d'artistes
+func GetOverlayPath() string {
+	path := os.Getenv("OVERLAY_PATH")
+	if path == "" {
+		return DefaultOverlayPath
+	}
+	return path
+}
+
+// GetImageCacheDir retourne le dossier du cache d'images d'artistes
+func GetImageCacheDir() string {
+	dir := os.Getenv("IMAGE_CACHE_DIR")
+	if dir == "" {
+		return DefaultImageCacheDir
+	}
+	return dir
+}
+
+// GetGazetteerPath retourne le chemin du gazetier hors ligne
+func GetGazetteerPath() string {
+	path := os.Getenv("GAZETTEER_PATH")
+	if path == "" {
+		return DefaultGazetteerPath
+	}
+	return path
+}
+
+// GetNominatimURL retourne l'URL du service de géocodage compatible Nominatim
+// ("" = désactivé : NOMINATIM_URL=off, ou mode hors ligne sans URL explicite)
+func GetNominatimURL() string {
+	url := os.Getenv("NOMINATIM_URL")
+	switch {
+	case url == "off":
+		return ""
+	case url != "":
+		return url
+	case IsOfflineMode():
+		return ""
+	}
+	return NominatimURL
+}
@@ -91,6 +91,74 @@ func createTables() error {
 		return fmt.Errorf("erreur lors de la création de la table sessions: %w", err)
 	}
 
+	savedSearchesQuery := `
+	CREATE TABLE IF NOT EXISTS saved_searches (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		name VARCHAR(255) NOT NULL,
+		criteria TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);`
+
+	_, err = DB.Exec(savedSearchesQuery)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de la table saved_searches: %w", err)
+	}
+
+	seenQuery := `
+	CREATE TABLE IF NOT EXISTS saved_search_seen (
+		search_id INT NOT NULL,
+		match_key VARCHAR(255) NOT NULL,
+		PRIMARY KEY (search_id, match_key),
+		FOREIGN KEY (search_id) REFERENCES saved_searches(id) ON DELETE CASCADE
+	);`
+
+	_, err = DB.Exec(seenQuery)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de la table saved_search_seen: %w", err)
+	}
+
+	notificationsQuery := `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		user_id INT NOT NULL,
+		search_id INT NOT NULL,
+		kind VARCHAR(20) NOT NULL,
+		artist_id INT NOT NULL,
+		artist_name VARCHAR(255) NOT NULL,
+		location VARCHAR(255) DEFAULT '',
+		concert_date DATE NULL,
+		message VARCHAR(512) NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		read_at TIMESTAMP NULL,
+		INDEX idx_notifications_user (user_id, created_at),
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+		FOREIGN KEY (search_id) REFERENCES saved_searches(id) ON DELETE CASCADE
+	);`
+
+	_, err = DB.Exec(notificationsQuery)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de la table notifications: %w", err)
+	}
+
+	geocodeQuery := `
+	CREATE TABLE IF NOT EXISTS geocode_cache (
+		location_key VARCHAR(255) PRIMARY KEY,
+		query VARCHAR(255) NOT NULL,
+		latitude DOUBLE NULL,
+		longitude DOUBLE NULL,
+		source VARCHAR(20) NOT NULL,
+		error VARCHAR(255) DEFAULT '',
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);`
+
+	_, err = DB.Exec(geocodeQuery)
+	if err != nil {
+		return fmt.Errorf("erreur lors de la création de la table geocode_cache: %w", err)
+	}
+
 	return migrateTables()
 }
 
@@ -102,6 +170,7 @@ func migrateTables() error {
 		"ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar VARCHAR(255) DEFAULT ''",
 		"ALTER TABLE users ADD COLUMN IF NOT EXISTS is_admin BOOLEAN DEFAULT FALSE",
 		"ALTER TABLE users ADD COLUMN IF NOT EXISTS is_owner BOOLEAN DEFAULT FALSE",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS city VARCHAR(255) DEFAULT ''",
 	}
 
 	for _, query := range alterQueries {
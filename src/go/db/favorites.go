@@ -0,0 +1,20 @@
+package db
+
+// FavoriteIDs retourne les identifiants des artistes favoris d'un utilisateur
+func FavoriteIDs(userID int) (map[int]struct{}, error) {
+	rows, err := DB.Query("SELECT artist_id FROM favorites WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	favorites := make(map[int]struct{})
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		favorites[id] = struct{}{}
+	}
+	return favorites, rows.Err()
+}
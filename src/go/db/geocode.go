@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+
+	"groupie/src/go/models"
+)
+
+// GeocodeStore expose la table geocode_cache à api.GeocodeService
+type GeocodeStore struct{}
+
+// LoadGeocode retourne l'entrée d'une clé ; ok est faux si elle n'est pas en cache
+func (GeocodeStore) LoadGeocode(key string) (models.GeocodeEntry, bool, error) {
+	entry, err := GetGeocode(key)
+	if err == sql.ErrNoRows {
+		return entry, false, nil
+	}
+	return entry, err == nil, err
+}
+
+// SaveGeocode enregistre ou remplace une entrée
+func (GeocodeStore) SaveGeocode(entry models.GeocodeEntry) error {
+	return SaveGeocode(entry)
+}
+
+const geocodeColumns = "location_key, query, latitude, longitude, source, error, updated_at"
+
+// GetGeocode retourne l'entrée d'une clé (sql.ErrNoRows si elle n'existe pas)
+func GetGeocode(key string) (models.GeocodeEntry, error) {
+	row := DB.QueryRow("SELECT "+geocodeColumns+" FROM geocode_cache WHERE location_key = ?", key)
+	return scanGeocode(row)
+}
+
+// SaveGeocode enregistre ou remplace une entrée du cache de géocodage
+func SaveGeocode(e models.GeocodeEntry) error {
+	var lat, lng interface{}
+	if e.Coordinates != nil {
+		lat, lng = e.Coordinates.Latitude, e.Coordinates.Longitude
+	}
+	_, err := DB.Exec(
+		`INSERT INTO geocode_cache (`+geocodeColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE query = VALUES(query), latitude = VALUES(latitude), longitude = VALUES(longitude),
+			source = VALUES(source), error = VALUES(error), updated_at = VALUES(updated_at)`,
+		e.Key, e.Query, lat, lng, e.Source, e.Error, e.UpdatedAt,
+	)
+	return err
+}
+
+// GeocodeFilter sélectionne des entrées du cache ; les champs vides ne filtrent pas
+type GeocodeFilter struct {
+	Search     string // partie de la clé
+	Source     string
+	FailedOnly bool
+}
+
+// likeEscaper protège les jokers de LIKE : "50%" ou "new_york" sont cherchés tels quels
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (f GeocodeFilter) where() (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if f.Search != "" {
+		conds = append(conds, `location_key LIKE ? ESCAPE '\\'`)
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(f.Search))+"%")
+	}
+	if f.Source != "" {
+		conds = append(conds, "source = ?")
+		args = append(args, f.Source)
+	}
+	if f.FailedOnly {
+		conds = append(conds, "latitude IS NULL")
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// ListGeocodes liste les entrées du cache, les plus récentes d'abord
+func ListGeocodes(f GeocodeFilter, limit int) ([]models.GeocodeEntry, error) {
+	where, args := f.where()
+	rows, err := DB.Query("SELECT "+geocodeColumns+" FROM geocode_cache"+where+" ORDER BY updated_at DESC LIMIT ?", append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.GeocodeEntry{}
+	for rows.Next() {
+		e, err := scanGeocode(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// PurgeGeocodes supprime les entrées sélectionnées et retourne leur nombre
+func PurgeGeocodes(f GeocodeFilter) (int64, error) {
+	where, args := f.where()
+	res, err := DB.Exec("DELETE FROM geocode_cache"+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteGeocode supprime l'entrée d'une clé (sql.ErrNoRows si elle n'existe pas)
+func DeleteGeocode(key string) error {
+	res, err := DB.Exec("DELETE FROM geocode_cache WHERE location_key = ?", key)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGeocode(row rowScanner) (models.GeocodeEntry, error) {
+	var e models.GeocodeEntry
+	var lat, lng sql.NullFloat64
+	if err := row.Scan(&e.Key, &e.Query, &lat, &lng, &e.Source, &e.Error, &e.UpdatedAt); err != nil {
+		return e, err
+	}
+	if lat.Valid && lng.Valid {
+		e.Coordinates = &models.Coordinates{Latitude: lat.Float64, Longitude: lng.Float64}
+	}
+	return e, nil
+}
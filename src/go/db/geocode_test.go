@@ -0,0 +1,27 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeocodeFilterEscapesLike(t *testing.T) {
+	tests := []struct {
+		search string
+		want   string
+	}{
+		{"Paris", "%paris%"},
+		{"new_york", `%new\_york%`},
+		{"100%", `%100\%%`},
+		{`a\b`, `%a\\b%`},
+	}
+	for _, tt := range tests {
+		where, args := GeocodeFilter{Search: tt.search}.where()
+		if !strings.Contains(where, `LIKE ? ESCAPE '\\'`) {
+			t.Errorf("where = %q, ESCAPE absent", where)
+		}
+		if len(args) != 1 || args[0] != tt.want {
+			t.Errorf("Search %q: args = %v, attendu %q", tt.search, args, tt.want)
+		}
+	}
+}
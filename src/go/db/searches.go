@@ -0,0 +1,222 @@
+package db
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// CreateSavedSearch enregistre une recherche et la retourne avec son identifiant
+func CreateSavedSearch(userID int, name, criteria string) (models.SavedSearch, error) {
+	res, err := DB.Exec("INSERT INTO saved_searches (user_id, name, criteria) VALUES (?, ?, ?)", userID, name, criteria)
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return models.SavedSearch{}, err
+	}
+	return GetSavedSearch(userID, int(id))
+}
+
+// GetSavedSearch retourne une recherche de l'utilisateur (sql.ErrNoRows si elle n'existe pas)
+func GetSavedSearch(userID, id int) (models.SavedSearch, error) {
+	var s models.SavedSearch
+	err := DB.QueryRow(
+		"SELECT id, user_id, name, criteria, created_at, updated_at FROM saved_searches WHERE id = ? AND user_id = ?",
+		id, userID,
+	).Scan(&s.ID, &s.UserID, &s.Name, &s.Criteria, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+// SavedSearches liste les recherches d'un utilisateur, les plus récentes d'abord
+func SavedSearches(userID int) ([]models.SavedSearch, error) {
+	return querySavedSearches("SELECT id, user_id, name, criteria, created_at, updated_at FROM saved_searches WHERE user_id = ? ORDER BY id DESC", userID)
+}
+
+// AllSavedSearches liste les recherches de tous les utilisateurs
+func AllSavedSearches() ([]models.SavedSearch, error) {
+	return querySavedSearches("SELECT id, user_id, name, criteria, created_at, updated_at FROM saved_searches ORDER BY id")
+}
+
+func querySavedSearches(query string, args ...interface{}) ([]models.SavedSearch, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []models.SavedSearch{}
+	for rows.Next() {
+		var s models.SavedSearch
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.Criteria, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+// CountSavedSearches compte les recherches d'un utilisateur
+func CountSavedSearches(userID int) (int, error) {
+	var n int
+	err := DB.QueryRow("SELECT COUNT(*) FROM saved_searches WHERE user_id = ?", userID).Scan(&n)
+	return n, err
+}
+
+// UpdateSavedSearch renomme une recherche ou change ses critères ; les correspondances déjà
+// vues sont oubliées quand les critères changent. Retourne sql.ErrNoRows si elle n'existe pas.
+func UpdateSavedSearch(userID, id int, name, criteria string) error {
+	current, err := GetSavedSearch(userID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE saved_searches SET name = ?, criteria = ? WHERE id = ?", name, criteria, id); err != nil {
+		return err
+	}
+	if criteria != current.Criteria {
+		if _, err := tx.Exec("DELETE FROM saved_search_seen WHERE search_id = ?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteSavedSearch supprime une recherche et ses notifications (sql.ErrNoRows si elle n'existe pas)
+func DeleteSavedSearch(userID, id int) error {
+	res, err := DB.Exec("DELETE FROM saved_searches WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SearchStore expose les recherches enregistrées à notify.Notifier
+type SearchStore struct{}
+
+func (SearchStore) AllSavedSearches() ([]models.SavedSearch, error) {
+	return AllSavedSearches()
+}
+
+func (SearchStore) FavoriteIDs(userID int) (map[int]struct{}, error) {
+	return FavoriteIDs(userID)
+}
+
+func (SearchStore) SeenMatchKeys(searchID int) (map[string]struct{}, error) {
+	return SeenMatchKeys(searchID)
+}
+
+func (SearchStore) RecordMatches(searchID, userID int, matches []models.SearchMatch, notifications []models.Notification) error {
+	return RecordMatches(searchID, userID, matches, notifications)
+}
+
+// SeenMatchKeys retourne les correspondances déjà enregistrées pour une recherche
+func SeenMatchKeys(searchID int) (map[string]struct{}, error) {
+	rows, err := DB.Query("SELECT match_key FROM saved_search_seen WHERE search_id = ?", searchID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]struct{})
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		seen[key] = struct{}{}
+	}
+	return seen, rows.Err()
+}
+
+// RecordMatches marque des correspondances comme vues et crée les notifications données,
+// dans une même transaction
+func RecordMatches(searchID, userID int, matches []models.SearchMatch, notifications []models.Notification) error {
+	if len(matches) == 0 {
+		return nil
+	}
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range matches {
+		if _, err := tx.Exec("INSERT IGNORE INTO saved_search_seen (search_id, match_key) VALUES (?, ?)", searchID, m.Key); err != nil {
+			return err
+		}
+	}
+	for _, n := range notifications {
+		var date interface{}
+		if n.Date != nil {
+			date = n.Date.Format("2006-01-02")
+		}
+		_, err := tx.Exec(
+			`INSERT INTO notifications (user_id, search_id, kind, artist_id, artist_name, location, concert_date, message)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			userID, searchID, n.Kind, n.ArtistID, n.ArtistName, n.Location, date, n.Message,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Notifications liste les notifications d'un utilisateur, les plus récentes d'abord
+func Notifications(userID int, unreadOnly bool, limit int) ([]models.Notification, error) {
+	query := `SELECT n.id, n.search_id, s.name, n.kind, n.artist_id, n.artist_name, n.location, n.concert_date, n.message, n.created_at, n.read_at
+		FROM notifications n JOIN saved_searches s ON s.id = n.search_id
+		WHERE n.user_id = ?`
+	if unreadOnly {
+		query += " AND n.read_at IS NULL"
+	}
+	query += " ORDER BY n.id DESC LIMIT ?"
+
+	rows, err := DB.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		var date, readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.SearchID, &n.SearchName, &n.Kind, &n.ArtistID, &n.ArtistName, &n.Location, &date, &n.Message, &n.CreatedAt, &readAt); err != nil {
+			return nil, err
+		}
+		if date.Valid {
+			n.Date = &date.Time
+		}
+		n.Read = readAt.Valid
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkNotificationsRead marque des notifications comme lues (toutes si ids est vide)
+func MarkNotificationsRead(userID int, ids []int) error {
+	query := "UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL"
+	args := []interface{}{time.Now(), userID}
+	if len(ids) > 0 {
+		query += " AND id IN (?" + strings.Repeat(", ?", len(ids)-1) + ")"
+		for _, id := range ids {
+			args = append(args, id)
+		}
+	}
+	_, err := DB.Exec(query, args...)
+	return err
+}
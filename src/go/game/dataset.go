@@ -0,0 +1,213 @@
+package game
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Dataset est un état du jeu de données, publié en bloc par DataService.
+// Il n'est jamais modifié après construction : les lecteurs le partagent sans verrou
+// ni copie et ne doivent pas modifier les artistes qu'il contient.
+type Dataset struct {
+	Artists   []models.Artist
+	Locations []string
+	UpdatedAt time.Time
+
+	// Index : position dans Artists, listes triées par position
+	byID       map[int]int
+	byLocation map[string][]int // lieu affiché et brut, normalisés avec utils.FoldText
+	byCountry  map[string][]int // pays normalisé
+	byMember   map[string][]int // nom de membre normalisé
+	members    []string         // clés de byMember, triées
+	search     *SearchIndex
+
+	// Ordres de tri des listes paginées (voir List) ; celui des prochains concerts
+	// dépend du jour et est calculé à la demande
+	names   []string // noms en minuscules, par position
+	orders  map[string]*listOrder
+	nextMu  sync.Mutex
+	next    *listOrder
+	nextDay time.Time
+}
+
+// NewDataset construit un jeu de données et ses index
+func NewDataset(artists []models.Artist, locations []string, updatedAt time.Time) *Dataset {
+	ds := &Dataset{
+		Artists:    artists,
+		Locations:  locations,
+		UpdatedAt:  updatedAt,
+		byID:       make(map[int]int, len(artists)),
+		byLocation: map[string][]int{},
+		byCountry:  map[string][]int{},
+		byMember:   map[string][]int{},
+		search:     BuildSearchIndex(artists),
+	}
+
+	add := func(index map[string][]int, key string, pos int) {
+		if key == "" {
+			return
+		}
+		// Positions croissantes : un doublon ne peut être que le dernier élément
+		if list := index[key]; len(list) == 0 || list[len(list)-1] != pos {
+			index[key] = append(list, pos)
+		}
+	}
+	for pos, art := range artists {
+		ds.byID[art.ID] = pos
+		for _, c := range art.Concerts {
+			add(ds.byLocation, utils.FoldText(c.DisplayLocation), pos)
+			add(ds.byLocation, utils.FoldText(c.Location), pos)
+			add(ds.byCountry, utils.FoldText(utils.LocationCountry(c.Location)), pos)
+		}
+		for _, m := range art.Members {
+			add(ds.byMember, utils.FoldText(m), pos)
+		}
+	}
+
+	ds.members = make([]string, 0, len(ds.byMember))
+	for m := range ds.byMember {
+		ds.members = append(ds.members, m)
+	}
+	sort.Strings(ds.members)
+
+	ds.names = make([]string, len(artists))
+	for pos, art := range artists {
+		ds.names[pos] = strings.ToLower(art.Name)
+	}
+	ds.orders = make(map[string]*listOrder, len(sortKeys))
+	for sortBy := range sortKeys {
+		if sortBy != SortNextConcert {
+			ds.orders[sortBy] = newListOrder(artists, ds.names, sortBy, updatedAt)
+		}
+	}
+	return ds
+}
+
+// Artist retourne l'artiste d'identifiant id
+func (ds *Dataset) Artist(id int) (models.Artist, bool) {
+	pos, ok := ds.byID[id]
+	if !ok {
+		return models.Artist{}, false
+	}
+	return ds.Artists[pos], true
+}
+
+// ArtistsByLocation liste les artistes ayant joué à un lieu ("Paris, France" ou "paris-france")
+func (ds *Dataset) ArtistsByLocation(location string) []models.Artist {
+	return ds.at(ds.byLocation[utils.FoldText(location)])
+}
+
+// HasLocation indique si un concert a lieu à cet endroit (lieu affiché ou brut)
+func (ds *Dataset) HasLocation(location string) bool {
+	_, ok := ds.byLocation[utils.FoldText(location)]
+	return ok
+}
+
+// ArtistsByCountry liste les artistes ayant joué dans un pays
+func (ds *Dataset) ArtistsByCountry(country string) []models.Artist {
+	return ds.at(ds.byCountry[utils.FoldText(country)])
+}
+
+// ArtistsByMember liste les artistes dont un membre porte exactement ce nom (casse et accents ignorés)
+func (ds *Dataset) ArtistsByMember(name string) []models.Artist {
+	return ds.at(ds.byMember[utils.FoldText(name)])
+}
+
+func (ds *Dataset) at(positions []int) []models.Artist {
+	out := make([]models.Artist, len(positions))
+	for i, pos := range positions {
+		out[i] = ds.Artists[pos]
+	}
+	return out
+}
+
+// Search cherche dans l'index de ce jeu de données
+func (ds *Dataset) Search(query string, limit int) models.SearchResponse {
+	return ds.search.Search(query, limit)
+}
+
+// Filter équivaut à FilterArtists(ds.Artists, criteria) ; les index par lieu, pays
+// et membre réduisent d'abord les candidats
+func (ds *Dataset) Filter(criteria models.FilterCriteria) []models.Artist {
+	candidates, placed, narrowed := ds.candidates(criteria)
+	if !narrowed {
+		return FilterArtists(ds.Artists, criteria)
+	}
+	check := criteria
+	if placed {
+		// L'index a déjà vérifié le seul critère de concert : inutile de normaliser
+		// chaque lieu à nouveau
+		check.Locations, check.Countries = nil, nil
+	}
+	result := make([]models.Artist, 0, len(candidates))
+	for _, pos := range candidates {
+		if MatchesCriteria(ds.Artists[pos], check) {
+			result = append(result, ds.Artists[pos])
+		}
+	}
+	return result
+}
+
+// candidates retourne, parmi les critères indexés, la plus courte liste de positions
+// qui contient forcément tous les résultats. placed indique que cette liste vient de l'index
+// de lieux ou de pays et que ce critère est le seul critère de concert : chaque candidat le respecte.
+func (ds *Dataset) candidates(criteria models.FilterCriteria) (best []int, placed, narrowed bool) {
+	// Seul critère de concert indexé : lieux, ou pays, sans rayon ni date
+	placeOnly := criteria.Center == nil && !criteria.UpcomingOnly && criteria.ConcertFrom.IsZero() &&
+		criteria.ConcertTo.IsZero() && (len(criteria.Locations) == 0 || len(criteria.Countries) == 0)
+	consider := func(positions []int, exact bool) {
+		if !narrowed || len(positions) < len(best) {
+			best, placed, narrowed = positions, exact, true
+		}
+	}
+
+	if len(criteria.Locations) > 0 {
+		var lists [][]int
+		for loc := range criteria.Locations {
+			lists = append(lists, ds.byLocation[loc])
+		}
+		consider(mergePositions(lists), placeOnly)
+	}
+	if len(criteria.Countries) > 0 {
+		var lists [][]int
+		for country := range criteria.Countries {
+			lists = append(lists, ds.byCountry[country])
+		}
+		consider(mergePositions(lists), placeOnly)
+	}
+	if criteria.Member != "" {
+		var lists [][]int
+		for _, m := range ds.members {
+			if strings.Contains(m, criteria.Member) {
+				lists = append(lists, ds.byMember[m])
+			}
+		}
+		consider(mergePositions(lists), false)
+	}
+	return best, placed, narrowed
+}
+
+// mergePositions réunit des listes triées en une liste triée sans doublon
+func mergePositions(lists [][]int) []int {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+	var out []int
+	for _, l := range lists {
+		out = append(out, l...)
+	}
+	sort.Ints(out)
+	n := 0
+	for i, pos := range out {
+		if i == 0 || pos != out[n-1] {
+			out[n] = pos
+			n++
+		}
+	}
+	return out[:n]
+}
@@ -0,0 +1,172 @@
+package game
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// newTestService charge le jeu d'essai embarqué ; snapshot et overlay sont dans un dossier temporaire
+func newTestService(t testing.TB) *DataService {
+	t.Helper()
+	dir := t.TempDir()
+	overlayPath := filepath.Join(dir, "overlay.json")
+	if err := os.WriteFile(overlayPath, []byte(`{"1": {"genres": ["Rock"]}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d := NewDataService(&models.App{}, api.NewFixtureSource(), filepath.Join(dir, "snapshot.json"), overlayPath)
+	d.SnapshotDelay = 0
+	if err := d.RefreshData(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// TestDataServiceConcurrentReaders lit le jeu de données pendant des remplacements ;
+// à lancer avec go test -race
+func TestDataServiceConcurrentReaders(t *testing.T) {
+	d := newTestService(t)
+	first := d.GetArtists()[0]
+	if len(first.Concerts) == 0 {
+		t.Fatal("jeu d'essai sans concert")
+	}
+	location := first.Concerts[0].Location
+	criteria := models.FilterCriteria{Locations: map[string]struct{}{utils.FoldText(location): {}}}
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				ds := d.Current()
+				for _, art := range ds.Artists {
+					got, ok := ds.Artist(art.ID)
+					if !ok || got.ID != art.ID {
+						t.Errorf("Artist(%d) = %d, %v", art.ID, got.ID, ok)
+						return
+					}
+				}
+				found := false
+				for _, art := range ds.Filter(criteria) {
+					found = found || art.ID == first.ID
+				}
+				if !found {
+					t.Errorf("Filter(%s) sans l'artiste %d", location, first.ID)
+					return
+				}
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	writers.Add(3)
+	go func() {
+		defer writers.Done()
+		for range 5 {
+			if err := d.RefreshData(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := range 20 {
+			d.SetCoordinates(map[string]models.Coordinates{location: {Latitude: float64(i), Longitude: 1}})
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for range 20 {
+			if err := d.ReloadOverlay(); err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	if art, _ := d.FindArtistByID(1); len(art.Genres) == 0 {
+		t.Error("overlay perdu après les remplacements")
+	}
+}
+
+// reloadingSource recharge l'overlay pendant le chargement des relations
+type reloadingSource struct {
+	api.DataSource
+	once   sync.Once
+	reload func()
+}
+
+func (s *reloadingSource) FetchRelations(ctx context.Context, relationsURL string) (*models.APIRelation, error) {
+	s.once.Do(s.reload)
+	return s.DataSource.FetchRelations(ctx, relationsURL)
+}
+
+// TestRefreshKeepsConcurrentOverlayReload vérifie qu'un ReloadOverlay survenu pendant
+// RefreshData n'est pas écrasé par l'overlay lu au début du rafraîchissement
+func TestRefreshKeepsConcurrentOverlayReload(t *testing.T) {
+	d := newTestService(t)
+	source := &reloadingSource{DataSource: d.Source}
+	source.reload = func() {
+		if err := os.WriteFile(d.OverlayPath, []byte(`{"1": {"genres": ["Jazz"]}}`), 0o644); err != nil {
+			t.Error(err)
+		}
+		if err := d.ReloadOverlay(); err != nil {
+			t.Error(err)
+		}
+	}
+	d.Source = source
+	if err := d.RefreshData(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if art, _ := d.FindArtistByID(1); len(art.Genres) != 1 || art.Genres[0] != "Jazz" {
+		t.Errorf("genres = %v, attendu [Jazz]", art.Genres)
+	}
+}
+
+func BenchmarkDatasetArtist(b *testing.B) {
+	ds := NewDataset(syntheticArtists(10000), nil, time.Time{})
+	b.ReportAllocs()
+	id := 0
+	for b.Loop() {
+		id = id%10000 + 1
+		if _, ok := ds.Artist(id); !ok {
+			b.Fatal("artiste introuvable")
+		}
+	}
+}
+
+func BenchmarkDatasetFilter(b *testing.B) {
+	ds := NewDataset(syntheticArtists(10000), nil, time.Time{})
+	benchmarks := []struct {
+		name     string
+		criteria models.FilterCriteria
+	}{
+		{"lieu", models.FilterCriteria{Locations: map[string]struct{}{"paris france": {}}}},
+		{"pays", models.FilterCriteria{Countries: map[string]struct{}{"japan": {}}}},
+		{"création", models.FilterCriteria{CreationMin: 1990, CreationMax: 1995}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				ds.Filter(bm.criteria)
+			}
+		})
+	}
+}
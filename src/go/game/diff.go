@@ -0,0 +1,123 @@
+package game
+
+import (
+	"sort"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+// DiffArtists compare deux jeux de données : artistes, concerts (lieux) et dates ajoutés ou retirés
+func DiffArtists(before, after []models.Artist) models.DataChange {
+	change := models.DataChange{At: time.Now()}
+
+	oldByID := make(map[int]models.Artist, len(before))
+	for _, art := range before {
+		oldByID[art.ID] = art
+	}
+	newByID := make(map[int]models.Artist, len(after))
+	for _, art := range after {
+		newByID[art.ID] = art
+	}
+
+	for _, art := range after {
+		prev, ok := oldByID[art.ID]
+		if !ok {
+			change.ArtistsAdded = append(change.ArtistsAdded, models.ArtistRef{ID: art.ID, Name: art.Name})
+			continue
+		}
+		diffConcerts(&change, prev, art)
+	}
+
+	for _, art := range before {
+		if _, ok := newByID[art.ID]; !ok {
+			change.ArtistsRemoved = append(change.ArtistsRemoved, models.ArtistRef{ID: art.ID, Name: art.Name})
+		}
+	}
+
+	return change
+}
+
+func diffConcerts(change *models.DataChange, prev, cur models.Artist) {
+	oldDates := make(map[string][]string, len(prev.Concerts))
+	for _, c := range prev.Concerts {
+		oldDates[c.Location] = c.Dates
+	}
+	newDates := make(map[string][]string, len(cur.Concerts))
+	for _, c := range cur.Concerts {
+		newDates[c.Location] = c.Dates
+	}
+
+	entry := func(c models.Concert, dates []string) models.ConcertChange {
+		return models.ConcertChange{
+			ArtistID:   cur.ID,
+			ArtistName: cur.Name,
+			Location:   c.DisplayLocation,
+			Dates:      dates,
+		}
+	}
+
+	for _, c := range cur.Concerts {
+		dates, ok := oldDates[c.Location]
+		if !ok {
+			change.ConcertsAdded = append(change.ConcertsAdded, entry(c, c.Dates))
+			continue
+		}
+		if added := missingDates(c.Dates, dates); len(added) > 0 {
+			change.DatesAdded = append(change.DatesAdded, entry(c, added))
+		}
+		if removed := missingDates(dates, c.Dates); len(removed) > 0 {
+			change.DatesRemoved = append(change.DatesRemoved, entry(c, removed))
+		}
+	}
+
+	for _, c := range prev.Concerts {
+		if _, ok := newDates[c.Location]; !ok {
+			change.ConcertsRemoved = append(change.ConcertsRemoved, entry(c, c.Dates))
+		}
+	}
+}
+
+// missingDates retourne les dates de src absentes de other
+func missingDates(src, other []string) []string {
+	set := make(map[string]struct{}, len(other))
+	for _, d := range other {
+		set[d] = struct{}{}
+	}
+	var out []string
+	for _, d := range src {
+		if _, ok := set[d]; !ok {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// IsEmptyChange indique qu'aucune différence n'a été constatée
+func IsEmptyChange(c models.DataChange) bool {
+	return len(c.ArtistsAdded) == 0 && len(c.ArtistsRemoved) == 0 &&
+		len(c.ConcertsAdded) == 0 && len(c.ConcertsRemoved) == 0 &&
+		len(c.DatesAdded) == 0 && len(c.DatesRemoved) == 0
+}
+
+func (d *DataService) recordChange(change models.DataChange) {
+	d.changesMu.Lock()
+	defer d.changesMu.Unlock()
+	d.changes = append(d.changes, change)
+	if len(d.changes) > config.MaxChangeHistory {
+		d.changes = d.changes[len(d.changes)-config.MaxChangeHistory:]
+	}
+}
+
+// ChangesSince retourne les différences enregistrées après la date donnée
+func (d *DataService) ChangesSince(since time.Time) []models.DataChange {
+	d.changesMu.RLock()
+	defer d.changesMu.RUnlock()
+	idx := sort.Search(len(d.changes), func(i int) bool {
+		return d.changes[i].At.After(since)
+	})
+	out := make([]models.DataChange, len(d.changes)-idx)
+	copy(out, d.changes[idx:])
+	return out
+}
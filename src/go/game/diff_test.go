@@ -0,0 +1,92 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// summarizeChange résume une différence en lignes comparables
+func summarizeChange(c models.DataChange) []string {
+	var out []string
+	for _, a := range c.ArtistsAdded {
+		out = append(out, fmt.Sprintf("+artiste %d", a.ID))
+	}
+	for _, a := range c.ArtistsRemoved {
+		out = append(out, fmt.Sprintf("-artiste %d", a.ID))
+	}
+	groups := []struct {
+		prefix  string
+		changes []models.ConcertChange
+	}{{"+concert", c.ConcertsAdded}, {"-concert", c.ConcertsRemoved}, {"+dates", c.DatesAdded}, {"-dates", c.DatesRemoved}}
+	for _, g := range groups {
+		for _, cc := range g.changes {
+			out = append(out, fmt.Sprintf("%s %d %s %v", g.prefix, cc.ArtistID, cc.Location, cc.Dates))
+		}
+	}
+	return out
+}
+
+func TestDiffArtists(t *testing.T) {
+	concert := func(location string, dates ...string) models.Concert {
+		return models.Concert{Location: location, DisplayLocation: location, Dates: dates}
+	}
+	queen := models.Artist{ID: 1, Name: "Queen", Concerts: []models.Concert{concert("paris", "01-01-2020", "02-01-2020")}}
+
+	tests := []struct {
+		name   string
+		before []models.Artist
+		after  []models.Artist
+		want   []string
+	}{
+		{"identiques", []models.Artist{queen}, []models.Artist{queen}, nil},
+		{"artiste ajouté", []models.Artist{queen}, []models.Artist{queen, {ID: 2, Name: "SOJA"}}, []string{"+artiste 2"}},
+		{"artiste retiré", []models.Artist{queen, {ID: 2, Name: "SOJA"}}, []models.Artist{queen}, []string{"-artiste 2"}},
+		{"concert ajouté", []models.Artist{queen},
+			[]models.Artist{{ID: 1, Name: "Queen", Concerts: []models.Concert{queen.Concerts[0], concert("lyon", "03-01-2020")}}},
+			[]string{"+concert 1 lyon [03-01-2020]"}},
+		{"concert retiré", []models.Artist{queen}, []models.Artist{{ID: 1, Name: "Queen"}},
+			[]string{"-concert 1 paris [01-01-2020 02-01-2020]"}},
+		{"dates ajoutée et retirée", []models.Artist{queen},
+			[]models.Artist{{ID: 1, Name: "Queen", Concerts: []models.Concert{concert("paris", "02-01-2020", "05-01-2020")}}},
+			[]string{"+dates 1 paris [05-01-2020]", "-dates 1 paris [01-01-2020]"}},
+		{"ordre des dates indifférent", []models.Artist{queen},
+			[]models.Artist{{ID: 1, Name: "Queen", Concerts: []models.Concert{concert("paris", "02-01-2020", "01-01-2020")}}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := DiffArtists(tt.before, tt.after)
+			if got := summarizeChange(change); !slices.Equal(got, tt.want) {
+				t.Errorf("DiffArtists = %q, attendu %q", got, tt.want)
+			}
+			if IsEmptyChange(change) != (len(tt.want) == 0) {
+				t.Errorf("IsEmptyChange = %v pour %q", IsEmptyChange(change), tt.want)
+			}
+		})
+	}
+}
+
+func TestChangesSince(t *testing.T) {
+	d := &DataService{}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		d.recordChange(models.DataChange{At: start.Add(time.Duration(i) * time.Hour)})
+	}
+	tests := []struct {
+		since time.Time
+		want  int
+	}{
+		{start.Add(-time.Hour), 3},
+		{start, 2},
+		{start.Add(90 * time.Minute), 1},
+		{start.Add(2 * time.Hour), 0},
+	}
+	for _, tt := range tests {
+		if got := len(d.ChangesSince(tt.since)); got != tt.want {
+			t.Errorf("ChangesSince(%s) = %d différences, attendu %d", tt.since.Format(time.Kitchen), got, tt.want)
+		}
+	}
+}
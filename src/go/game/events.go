@@ -0,0 +1,111 @@
+package game
+
+import (
+	"sort"
+	"time"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Filtres temporels sur les concerts
+const (
+	EventsAll      = ""
+	EventsUpcoming = "upcoming"
+	EventsPast     = "past"
+)
+
+// BuildEvents éclate les concerts en un événement par lieu et par date, triés chronologiquement.
+// Les dates illisibles sont ignorées.
+func BuildEvents(concerts []models.Concert) []models.ConcertEvent {
+	events := make([]models.ConcertEvent, 0, len(concerts))
+	for _, c := range concerts {
+		for _, raw := range c.Dates {
+			date, ok := utils.ParseDate(raw)
+			if !ok {
+				continue
+			}
+			events = append(events, models.ConcertEvent{
+				Location:        c.Location,
+				DisplayLocation: c.DisplayLocation,
+				Date:            date,
+				Coordinates:     c.Coordinates,
+			})
+		}
+	}
+	SortEvents(events)
+	return events
+}
+
+// SortEvents trie les événements par date puis par lieu
+func SortEvents(events []models.ConcertEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].DisplayLocation < events[j].DisplayLocation
+	})
+}
+
+// IsUpcoming indique si le concert a lieu aujourd'hui ou plus tard
+func IsUpcoming(e models.ConcertEvent, now time.Time) bool {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return !e.Date.Before(today)
+}
+
+// FilterEvents garde les concerts à venir ou passés (when vide = tous)
+func FilterEvents(events []models.ConcertEvent, when string, now time.Time) []models.ConcertEvent {
+	if when == EventsAll {
+		return events
+	}
+	out := make([]models.ConcertEvent, 0, len(events))
+	for _, e := range events {
+		if IsUpcoming(e, now) == (when == EventsUpcoming) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// NextEvent retourne le prochain concert de l'artiste
+func NextEvent(art models.Artist, now time.Time) (models.ConcertEvent, bool) {
+	for _, e := range art.Events {
+		if IsUpcoming(e, now) {
+			return e, true
+		}
+	}
+	return models.ConcertEvent{}, false
+}
+
+// ListEvents retourne les concerts de plusieurs artistes dans l'ordre chronologique
+func ListEvents(artists []models.Artist, when string, now time.Time) []models.ArtistEvent {
+	out := []models.ArtistEvent{}
+	for _, art := range artists {
+		for _, e := range FilterEvents(art.Events, when, now) {
+			out = append(out, models.ArtistEvent{ArtistID: art.ID, ArtistName: art.Name, ConcertEvent: e})
+		}
+	}
+	sortArtistEvents(out)
+	return out
+}
+
+// NextEvents retourne le prochain concert de chaque artiste, du plus proche au plus lointain
+func NextEvents(artists []models.Artist, now time.Time) []models.ArtistEvent {
+	out := []models.ArtistEvent{}
+	for _, art := range artists {
+		if e, ok := NextEvent(art, now); ok {
+			out = append(out, models.ArtistEvent{ArtistID: art.ID, ArtistName: art.Name, ConcertEvent: e})
+		}
+	}
+	sortArtistEvents(out)
+	return out
+}
+
+func sortArtistEvents(events []models.ArtistEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].ArtistName < events[j].ArtistName
+	})
+}
@@ -0,0 +1,143 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// memberBucketStarts sont les bornes basses des tranches de nombre de membres
+var memberBucketStarts = []int{1, 2, 4, 6, 8}
+
+func artistCreationYear(a models.Artist) int   { return a.CreationDate }
+func artistFirstAlbumYear(a models.Artist) int { return a.FirstAlbumYear }
+
+// memberBuckets découpe [lo, hi] en tranches (1, 2-3, 4-5, 6-7, 8+) ; la dernière s'arrête à hi
+func memberBuckets(lo, hi int) []models.FacetRange {
+	var out []models.FacetRange
+	for i, start := range memberBucketStarts {
+		end := hi
+		if i+1 < len(memberBucketStarts) {
+			end = min(hi, memberBucketStarts[i+1]-1)
+		}
+		if end < lo || start > hi {
+			continue
+		}
+		start = max(start, lo)
+		r := models.FacetRange{Min: start, Max: end}
+		switch {
+		case i+1 == len(memberBucketStarts):
+			r.Key = strconv.Itoa(start) + "+"
+		case start == end:
+			r.Key = strconv.Itoa(start)
+		default:
+			r.Key = strconv.Itoa(start) + "-" + strconv.Itoa(end)
+		}
+		r.Label = r.Key + " membre"
+		if end > 1 {
+			r.Label += "s"
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// decadeRanges liste les décennies présentes (années inconnues ignorées), ex: "1970" pour 1970-1979
+func decadeRanges(artists []models.Artist, year func(models.Artist) int) []models.FacetRange {
+	set := map[int]struct{}{}
+	for _, a := range artists {
+		if y := year(a); y > 0 {
+			set[y/10*10] = struct{}{}
+		}
+	}
+	starts := make([]int, 0, len(set))
+	for d := range set {
+		starts = append(starts, d)
+	}
+	sort.Ints(starts)
+
+	out := make([]models.FacetRange, 0, len(starts))
+	for _, d := range starts {
+		key := strconv.Itoa(d)
+		out = append(out, models.FacetRange{Key: key, Label: "Années " + key, Min: d, Max: d + 9})
+	}
+	return out
+}
+
+// BuildFacets compte, pour chaque valeur de facette décrite par meta, les artistes qui
+// correspondraient aux critères courants si l'on choisissait cette valeur : le critère
+// de la dimension comptée est ignoré, tous les autres s'appliquent.
+func BuildFacets(artists []models.Artist, meta models.FilterMeta, criteria models.FilterCriteria) models.Facets {
+	without := func(clear func(c *models.FilterCriteria)) (models.FilterCriteria, []models.Artist) {
+		c := criteria
+		clear(&c)
+		return c, FilterArtists(artists, c)
+	}
+
+	c, base := without(func(c *models.FilterCriteria) { c.Locations = nil })
+	locations := countPlaces(base, c, func(location, displayLocation string) string { return displayLocation })
+
+	c, base = without(func(c *models.FilterCriteria) { c.Countries = nil })
+	countries := countPlaces(base, c, func(location, displayLocation string) string { return utils.LocationCountry(location) })
+
+	_, base = without(func(c *models.FilterCriteria) { c.MembersMin, c.MembersMax = 0, 0 })
+	members := countRanges(base, meta.MemberBuckets, func(a models.Artist) int { return len(a.Members) })
+
+	_, base = without(func(c *models.FilterCriteria) { c.CreationMin, c.CreationMax = 0, 0 })
+	creation := countRanges(base, meta.CreationDecades, artistCreationYear)
+
+	_, base = without(func(c *models.FilterCriteria) { c.AlbumMin, c.AlbumMax = 0, 0 })
+	album := countRanges(base, meta.AlbumDecades, artistFirstAlbumYear)
+
+	return models.Facets{
+		Locations:       valueCounts(meta.Locations, locations),
+		Countries:       valueCounts(meta.Countries, countries),
+		Members:         members,
+		CreationDecades: creation,
+		AlbumDecades:    album,
+	}
+}
+
+// countPlaces compte une fois par artiste chaque lieu (ou pays) d'un concert retenu
+func countPlaces(artists []models.Artist, criteria models.FilterCriteria, key func(location, displayLocation string) string) map[string]int {
+	counts := map[string]int{}
+	for _, art := range artists {
+		seen := map[string]struct{}{}
+		forEachMatchingConcert(art, criteria, func(location, displayLocation string) bool {
+			k := key(location, displayLocation)
+			if _, ok := seen[k]; !ok {
+				seen[k] = struct{}{}
+				counts[k]++
+			}
+			return true
+		})
+	}
+	return counts
+}
+
+func valueCounts(values []string, counts map[string]int) []models.FacetCount {
+	out := make([]models.FacetCount, 0, len(values))
+	for _, v := range values {
+		out = append(out, models.FacetCount{FacetRange: models.FacetRange{Key: v, Label: v}, Count: counts[v]})
+	}
+	return out
+}
+
+func countRanges(artists []models.Artist, ranges []models.FacetRange, value func(models.Artist) int) []models.FacetCount {
+	out := make([]models.FacetCount, len(ranges))
+	for i, r := range ranges {
+		out[i].FacetRange = r
+	}
+	for _, a := range artists {
+		v := value(a)
+		for i, r := range ranges {
+			if v >= r.Min && v <= r.Max {
+				out[i].Count++
+				break
+			}
+		}
+	}
+	return out
+}
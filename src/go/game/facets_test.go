@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// facetSummary résume une dimension en "clé=compte" séparés par des espaces
+func facetSummary(counts []models.FacetCount) string {
+	parts := make([]string, len(counts))
+	for i, c := range counts {
+		parts[i] = fmt.Sprintf("%s=%d", c.Key, c.Count)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestBuildFacets(t *testing.T) {
+	artist := func(id, members, creation int, locations ...string) models.Artist {
+		a := models.Artist{ID: id, Name: fmt.Sprint("artiste ", id), Members: make([]string, members), CreationDate: creation, FirstAlbumYear: creation + 1}
+		for _, loc := range locations {
+			a.Concerts = append(a.Concerts, models.Concert{Location: loc, DisplayLocation: utils.PrettifyLocation(loc), Dates: []string{"01-01-2020"}})
+		}
+		return a
+	}
+	artists := []models.Artist{
+		artist(1, 1, 1975, "paris-france", "london-uk"),
+		artist(2, 3, 1982, "paris-france"),
+		artist(3, 5, 1988, "lyon-france"),
+		artist(4, 8, 1995, "london-uk"),
+	}
+	meta := models.FilterMeta{
+		Locations:       []string{"London, UK", "Lyon, France", "Paris, France"},
+		Countries:       []string{"France", "UK"},
+		MemberBuckets:   memberBuckets(1, 8),
+		CreationDecades: decadeRanges(artists, artistCreationYear),
+		AlbumDecades:    decadeRanges(artists, artistFirstAlbumYear),
+	}
+
+	tests := []struct {
+		name      string
+		criteria  models.FilterCriteria
+		locations string
+		countries string
+		members   string
+		creation  string
+	}{
+		{
+			name:      "sans critère",
+			locations: "London, UK=2 Lyon, France=1 Paris, France=2",
+			countries: "France=3 UK=2",
+			members:   "1=1 2-3=1 4-5=1 6-7=0 8+=1",
+			creation:  "1970=1 1980=2 1990=1",
+		},
+		{
+			// Le lieu choisi n'influence pas sa propre facette ; les pays ne comptent que ses concerts
+			name:      "lieu",
+			criteria:  models.FilterCriteria{Locations: map[string]struct{}{"paris france": {}}},
+			locations: "London, UK=2 Lyon, France=1 Paris, France=2",
+			countries: "France=2 UK=0",
+			members:   "1=1 2-3=1 4-5=0 6-7=0 8+=0",
+			creation:  "1970=1 1980=1 1990=0",
+		},
+		{
+			name:      "membres",
+			criteria:  models.FilterCriteria{MembersMin: 4},
+			locations: "London, UK=1 Lyon, France=1 Paris, France=0",
+			countries: "France=1 UK=1",
+			members:   "1=1 2-3=1 4-5=1 6-7=0 8+=1",
+			creation:  "1970=0 1980=1 1990=1",
+		},
+		{
+			name:      "pays et création",
+			criteria:  models.FilterCriteria{Countries: map[string]struct{}{"france": {}}, CreationMin: 1980},
+			locations: "London, UK=0 Lyon, France=1 Paris, France=1",
+			countries: "France=2 UK=1",
+			members:   "1=0 2-3=1 4-5=1 6-7=0 8+=0",
+			creation:  "1970=1 1980=2 1990=0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facets := BuildFacets(artists, meta, tt.criteria)
+			for _, dim := range []struct {
+				name string
+				got  []models.FacetCount
+				want string
+			}{
+				{"lieux", facets.Locations, tt.locations},
+				{"pays", facets.Countries, tt.countries},
+				{"membres", facets.Members, tt.members},
+				{"création", facets.CreationDecades, tt.creation},
+			} {
+				if got := facetSummary(dim.got); got != dim.want {
+					t.Errorf("%s : %s, attendu %s", dim.name, got, dim.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Opérateurs du DSL de filtre
+const (
+	ExprAnd = "and"
+	ExprOr  = "or"
+	ExprNot = "not"
+)
+
+// FilterExpr est un arbre de filtre compilé depuis le JSON de POST /api/filter, ex:
+//
+//	{"and":[{"or":[{"location":"paris-france"},{"location":"lyon-france"}]},{"not":{"membersMin":5}}]}
+//
+// Une feuille porte un ou plusieurs critères, combinés comme ceux de la query string
+// (les critères de concert doivent être vérifiés par un même concert).
+type FilterExpr struct {
+	op       string
+	children []*FilterExpr
+	criteria models.FilterCriteria
+}
+
+// FilterExprError désigne le nœud fautif par son chemin, ex: "$.and[1].not.membersMin"
+type FilterExprError struct {
+	Path    string
+	Message string
+}
+
+func (e *FilterExprError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// exprFields sont les critères acceptés dans une feuille
+var exprFields = map[string]bool{
+	"location": true, "country": true, "member": true,
+	"creationMin": true, "creationMax": true,
+	"albumMin": true, "albumMax": true,
+	"membersMin": true, "membersMax": true,
+	"dateFrom": true, "dateTo": true,
+	"upcoming": true, "favorites": true,
+	"lat": true, "lng": true, "radiusKm": true,
+}
+
+type exprParser struct {
+	nodes int
+	now   time.Time
+}
+
+// ParseFilterExpr valide et compile un arbre de filtre JSON.
+// La taille est bornée par config.MaxFilterNodes et config.MaxFilterDepth.
+func ParseFilterExpr(data []byte, now time.Time) (*FilterExpr, error) {
+	p := &exprParser{now: now}
+	return p.parse(json.RawMessage(data), "$", 0)
+}
+
+func (p *exprParser) parse(raw json.RawMessage, path string, depth int) (*FilterExpr, error) {
+	p.nodes++
+	if p.nodes > config.MaxFilterNodes {
+		return nil, &FilterExprError{path, fmt.Sprintf("expression trop grande (%d nœuds au plus)", config.MaxFilterNodes)}
+	}
+	if depth >= config.MaxFilterDepth {
+		return nil, &FilterExprError{path, fmt.Sprintf("expression trop profonde (%d niveaux au plus)", config.MaxFilterDepth)}
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil || obj == nil {
+		return nil, &FilterExprError{path, "objet attendu"}
+	}
+	if len(obj) == 0 {
+		return nil, &FilterExprError{path, "nœud vide"}
+	}
+
+	for _, op := range []string{ExprAnd, ExprOr, ExprNot} {
+		child, ok := obj[op]
+		if !ok {
+			continue
+		}
+		if len(obj) > 1 {
+			return nil, &FilterExprError{path, fmt.Sprintf("'%s' doit être la seule clé du nœud", op)}
+		}
+		path += "." + op
+		if op == ExprNot {
+			inner, err := p.parse(child, path, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			return &FilterExpr{op: op, children: []*FilterExpr{inner}}, nil
+		}
+
+		var items []json.RawMessage
+		if err := json.Unmarshal(child, &items); err != nil {
+			return nil, &FilterExprError{path, "tableau attendu"}
+		}
+		if len(items) == 0 {
+			return nil, &FilterExprError{path, "tableau vide"}
+		}
+		expr := &FilterExpr{op: op}
+		for i, item := range items {
+			inner, err := p.parse(item, fmt.Sprintf("%s[%d]", path, i), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			expr.children = append(expr.children, inner)
+		}
+		return expr, nil
+	}
+
+	return p.parseLeaf(obj, path)
+}
+
+func (p *exprParser) parseLeaf(obj map[string]json.RawMessage, path string) (*FilterExpr, error) {
+	// Ordre stable pour que l'erreur signalée ne dépende pas du parcours de la map
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	c := models.FilterCriteria{Now: p.now}
+	var lat, lng *float64
+	for _, key := range keys {
+		raw := obj[key]
+		fieldPath := path + "." + key
+		fail := func(msg string) error { return &FilterExprError{fieldPath, msg} }
+		if !exprFields[key] {
+			return nil, fail("critère inconnu")
+		}
+
+		switch key {
+		case "location", "country", "member":
+			var v string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fail("chaîne attendue")
+			}
+			folded := utils.FoldText(v)
+			if folded == "" {
+				return nil, fail("valeur vide")
+			}
+			switch key {
+			case "location":
+				c.Locations = map[string]struct{}{folded: {}}
+			case "country":
+				c.Countries = map[string]struct{}{folded: {}}
+			default:
+				c.Member = folded
+			}
+
+		case "creationMin", "creationMax", "albumMin", "albumMax", "membersMin", "membersMax":
+			v, ok := exprInt(raw)
+			if !ok {
+				return nil, fail("entier positif attendu")
+			}
+			switch key {
+			case "creationMin":
+				c.CreationMin = v
+			case "creationMax":
+				c.CreationMax = v
+			case "albumMin":
+				c.AlbumMin = v
+			case "albumMax":
+				c.AlbumMax = v
+			case "membersMin":
+				c.MembersMin = v
+			default:
+				c.MembersMax = v
+			}
+
+		case "dateFrom", "dateTo":
+			var v string
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fail("date attendue (AAAA-MM-JJ)")
+			}
+			t, ok := parseDateParam(v)
+			if !ok {
+				return nil, fail("date invalide (AAAA-MM-JJ)")
+			}
+			if key == "dateFrom" {
+				c.ConcertFrom = t
+			} else {
+				c.ConcertTo = t
+			}
+
+		case "upcoming", "favorites":
+			var v bool
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fail("booléen attendu")
+			}
+			if key == "upcoming" {
+				c.UpcomingOnly = v
+			} else {
+				c.FavoritesOnly = v
+			}
+
+		case "lat", "lng", "radiusKm":
+			var v float64
+			if err := json.Unmarshal(raw, &v); err != nil {
+				return nil, fail("nombre attendu")
+			}
+			switch {
+			case key == "lat" && math.Abs(v) <= 90:
+				lat = &v
+			case key == "lng" && math.Abs(v) <= 180:
+				lng = &v
+			case key == "radiusKm" && v > 0 && v <= config.MaxRadiusKm:
+				c.RadiusKm = v
+			default:
+				return nil, fail("valeur hors limites")
+			}
+		}
+	}
+
+	if (lat == nil) != (lng == nil) {
+		return nil, &FilterExprError{path, "'lat' et 'lng' doivent être fournis ensemble"}
+	}
+	if lat != nil {
+		c.Center = &models.Coordinates{Latitude: *lat, Longitude: *lng}
+		if c.RadiusKm == 0 {
+			c.RadiusKm = config.DefaultRadiusKm
+		}
+	} else if c.RadiusKm != 0 {
+		return nil, &FilterExprError{path, "'radiusKm' sans 'lat'/'lng'"}
+	}
+	return &FilterExpr{criteria: c}, nil
+}
+
+// exprInt accepte un nombre JSON entier et positif
+func exprInt(raw json.RawMessage) (int, bool) {
+	// json.Number accepterait aussi une chaîne ("5")
+	if len(raw) == 0 || raw[0] == '"' {
+		return 0, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var n json.Number
+	if err := dec.Decode(&n); err != nil || strings.ContainsAny(n.String(), ".eE") {
+		return 0, false
+	}
+	v, err := n.Int64()
+	if err != nil || v < 0 || v > math.MaxInt32 {
+		return 0, false
+	}
+	return int(v), true
+}
+
+// Match évalue l'arbre pour un artiste
+func (e *FilterExpr) Match(art models.Artist) bool {
+	switch e.op {
+	case ExprAnd:
+		for _, c := range e.children {
+			if !c.Match(art) {
+				return false
+			}
+		}
+		return true
+	case ExprOr:
+		for _, c := range e.children {
+			if c.Match(art) {
+				return true
+			}
+		}
+		return false
+	case ExprNot:
+		return !e.children[0].Match(art)
+	default:
+		return MatchesCriteria(art, e.criteria)
+	}
+}
+
+// UsesFavorites indique si une feuille demande les favoris de l'utilisateur
+func (e *FilterExpr) UsesFavorites() bool {
+	if e.op == "" {
+		return e.criteria.FavoritesOnly
+	}
+	for _, c := range e.children {
+		if c.UsesFavorites() {
+			return true
+		}
+	}
+	return false
+}
+
+// SetFavorites renseigne les favoris de l'utilisateur dans toutes les feuilles
+func (e *FilterExpr) SetFavorites(favorites map[int]struct{}) {
+	e.criteria.Favorites = favorites
+	for _, c := range e.children {
+		c.SetFavorites(favorites)
+	}
+}
+
+// FilterArtistsExpr garde les artistes qui satisfont l'arbre
+func FilterArtistsExpr(artists []models.Artist, expr *FilterExpr) []models.Artist {
+	result := make([]models.Artist, 0, len(artists)/2)
+	for _, art := range artists {
+		if expr.Match(art) {
+			result = append(result, art)
+		}
+	}
+	return result
+}
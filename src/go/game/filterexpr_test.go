@@ -0,0 +1,65 @@
+package game
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"groupie/src/go/config"
+)
+
+func TestParseFilterExprErrors(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	deep := strings.Repeat(`{"not":`, config.MaxFilterDepth) + `{"membersMin":1}` + strings.Repeat("}", config.MaxFilterDepth)
+	wide := `{"or":[` + strings.Repeat(`{"membersMin":1},`, config.MaxFilterNodes) + `{"membersMin":1}]}`
+
+	tests := []struct {
+		name    string
+		expr    string
+		path    string // "" si l'expression est valide
+		message string
+	}{
+		{"feuille", `{"location":"paris-france","membersMin":3}`, "", ""},
+		{"arbre", `{"and":[{"or":[{"country":"france"},{"country":"uk"}]},{"not":{"upcoming":true}}]}`, "", ""},
+		{"centre et rayon", `{"lat":48.8,"lng":2.3,"radiusKm":50}`, "", ""},
+		{"pas un objet", `[1]`, "$", "objet attendu"},
+		{"nœud vide", `{}`, "$", "nœud vide"},
+		{"opérateur avec autre clé", `{"and":[{"membersMin":1}],"membersMin":2}`, "$", "'and' doit être la seule clé"},
+		{"and sans tableau", `{"and":{"membersMin":1}}`, "$.and", "tableau attendu"},
+		{"or vide", `{"or":[]}`, "$.or", "tableau vide"},
+		{"critère inconnu imbriqué", `{"and":[{"membersMin":1},{"not":{"genre":"rock"}}]}`, "$.and[1].not.genre", "critère inconnu"},
+		{"entier en chaîne", `{"or":[{"membersMin":"5"}]}`, "$.or[0].membersMin", "entier positif attendu"},
+		{"entier décimal", `{"creationMin":1990.5}`, "$.creationMin", "entier positif attendu"},
+		{"entier négatif", `{"albumMax":-1}`, "$.albumMax", "entier positif attendu"},
+		{"chaîne vide", `{"location":"  "}`, "$.location", "valeur vide"},
+		{"date invalide", `{"not":{"dateFrom":"01/02/2024"}}`, "$.not.dateFrom", "date invalide"},
+		{"booléen attendu", `{"upcoming":"yes"}`, "$.upcoming", "booléen attendu"},
+		{"latitude hors limites", `{"lat":91,"lng":0}`, "$.lat", "valeur hors limites"},
+		{"lat sans lng", `{"and":[{"lat":48.8}]}`, "$.and[0]", "'lat' et 'lng' doivent être fournis ensemble"},
+		{"rayon sans centre", `{"radiusKm":10}`, "$", "'radiusKm' sans 'lat'/'lng'"},
+		// Plusieurs erreurs dans une feuille : la première clé dans l'ordre alphabétique
+		{"erreur stable", `{"zzz":1,"membersMin":"x","albumMin":"y"}`, "$.albumMin", "entier positif attendu"},
+		{"trop profond", deep, "$" + strings.Repeat(".not", config.MaxFilterDepth), "expression trop profonde"},
+		{"trop grand", wide, "$.or[" + strconv.Itoa(config.MaxFilterNodes-1) + "]", "expression trop grande"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilterExpr([]byte(tt.expr), now)
+			if tt.path == "" {
+				if err != nil || expr == nil {
+					t.Fatalf("ParseFilterExpr: %v", err)
+				}
+				return
+			}
+			var exprErr *FilterExprError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("err = %v, attendu une FilterExprError", err)
+			}
+			if exprErr.Path != tt.path || !strings.Contains(exprErr.Message, tt.message) {
+				t.Errorf("erreur %q, attendu %s: %s", err, tt.path, tt.message)
+			}
+		})
+	}
+}
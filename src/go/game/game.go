@@ -3,62 +3,131 @@ package game
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"groupie/src/go/api"
+	"groupie/src/go/config"
 	"groupie/src/go/geo"
 	"groupie/src/go/models"
 	"groupie/src/go/utils"
 )
 
 type DataService struct {
-	App    *models.App
-	Client *api.Client
+	App          *models.App
+	Source       api.DataSource
+	SnapshotPath string
+	OverlayPath  string
+	// SnapshotDelay regroupe les sauvegardes demandées par SetCoordinates (0 : immédiate)
+	SnapshotDelay time.Duration
+
+	reportMu   sync.RWMutex
+	lastReport models.RefreshReport
+	quality    models.QualityReport
+
+	changesMu sync.RWMutex
+	changes   []models.DataChange
+
+	overlayMu sync.RWMutex
+	overlay   map[int]models.ArtistMeta
+
+	// snapshotMu sérialise les écritures du snapshot ; savePending signale une sauvegarde programmée
+	snapshotMu  sync.Mutex
+	savePending atomic.Bool
+
+	// data est le jeu de données courant, remplacé en bloc ; writeMu ordonne les remplacements
+	data    atomic.Pointer[Dataset]
+	writeMu sync.Mutex
+
+	jobsMu     sync.Mutex
+	jobs       map[string]*models.RefreshJob
+	jobOrder   []string
+	currentJob *models.RefreshJob
+
+	// refreshHooks sont appelés après chaque rafraîchissement réussi ; enregistrés au démarrage
+	refreshHooks []func([]models.Artist)
 }
 
-func NewDataService(app *models.App, client *api.Client) *DataService {
-	return &DataService{
-		App:    app,
-		Client: client,
+func NewDataService(app *models.App, source api.DataSource, snapshotPath, overlayPath string) *DataService {
+	d := &DataService{
+		App:           app,
+		Source:        source,
+		SnapshotPath:  snapshotPath,
+		OverlayPath:   overlayPath,
+		SnapshotDelay: config.SnapshotSaveDelay,
 	}
+	d.data.Store(NewDataset(nil, nil, time.Time{}))
+	return d
 }
 
 func (d *DataService) RefreshData(ctx context.Context) error {
-	index, err := d.Client.FetchIndex(ctx)
+	report := models.RefreshReport{StartedAt: time.Now()}
+	defer func() {
+		report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+		d.reportMu.Lock()
+		d.lastReport = report
+		d.reportMu.Unlock()
+	}()
+
+	index, err := d.Source.FetchIndex(ctx)
 	if err != nil {
+		report.Error = err.Error()
+		report.RetryAfterMs = retryAfter(err).Milliseconds()
 		return fmt.Errorf("index api: %w", err)
 	}
 
-	remoteArtists, err := d.Client.FetchArtists(ctx, index.ArtistsURL)
+	remoteArtists, err := d.Source.FetchArtists(ctx, index.ArtistsURL)
 	if err != nil {
+		report.Error = err.Error()
+		report.RetryAfterMs = retryAfter(err).Milliseconds()
 		return fmt.Errorf("chargement artistes: %w", err)
 	}
 
-	out := make([]models.Artist, len(remoteArtists))
-	errCh := make(chan error, len(remoteArtists))
-	var wg sync.WaitGroup
+	before := d.GetArtists()
+	previous := make(map[int]models.Artist, len(before))
+	for _, art := range before {
+		previous[art.ID] = art
+	}
 
-	for i, apiArt := range remoteArtists {
-		wg.Add(1)
-		go func(idx int, data models.APIArtist) {
-			defer wg.Done()
-			rel, err := d.Client.FetchRelations(ctx, data.RelationsURL)
-			if err != nil {
-				errCh <- fmt.Errorf("relation artiste %d: %w", data.ID, err)
-				return
-			}
-			out[idx] = CombineArtist(data, *rel)
-		}(i, apiArt)
+	sources := d.fetchCrossSources(ctx, index)
+	overlay := d.refreshOverlay()
+	results := d.fetchAllRelations(ctx, remoteArtists, sources, overlay)
+	quality := buildQualityReport(results)
+
+	out := make([]models.Artist, 0, len(remoteArtists))
+	report.Total = len(remoteArtists)
+	report.Artists = make([]models.ArtistRefreshResult, len(results))
+	for i, res := range results {
+		report.Artists[i] = res.ArtistRefreshResult
+		if res.Attempts > 1 {
+			report.Retried++
+		}
+		if res.Status == refreshOK {
+			report.Succeeded++
+			out = append(out, res.artist)
+			continue
+		}
+
+		report.Failed++
+		report.RetryAfterMs = max(report.RetryAfterMs, res.retryAfter.Milliseconds())
+		if prev, ok := previous[res.ID]; ok {
+			report.Artists[i].Status = refreshKept
+			out = append(out, prev)
+		}
+		log.Printf("refresh artiste %d: %s", res.ID, res.Error)
 	}
 
-	wg.Wait()
-	close(errCh)
-	if len(errCh) > 0 {
-		return <-errCh
+	if len(out) == 0 && report.Total > 0 {
+		report.Error = "aucun artiste n'a pu être chargé"
+		return fmt.Errorf("rafraîchissement: %s", report.Error)
 	}
 
 	sort.Slice(out, func(i, j int) bool {
@@ -78,48 +147,124 @@ func (d *DataService) RefreshData(ctx context.Context) error {
 	}
 	sort.Strings(locations)
 
-	d.App.Mu.Lock()
-	d.App.Artists = out
-	d.App.Locations = locations
-	d.App.Mu.Unlock()
+	// L'overlay est réappliqué au moment de la bascule : un ReloadOverlay survenu pendant
+	// le rafraîchissement n'est pas écrasé. Les lecteurs ne sont jamais bloqués.
+	d.writeMu.Lock()
+	overlay = d.getOverlay()
+	for i := range out {
+		ApplyMeta(&out[i], lookupMeta(overlay, out[i].ID))
+	}
+	d.data.Store(NewDataset(out, locations, time.Now()))
+	d.writeMu.Unlock()
+
+	d.reportMu.Lock()
+	d.quality = quality
+	d.reportMu.Unlock()
+
+	if len(before) > 0 {
+		if change := DiffArtists(before, out); !IsEmptyChange(change) {
+			d.recordChange(change)
+		}
+	}
+
+	if err := d.SaveSnapshot(); err != nil {
+		log.Printf("snapshot: %v", err)
+	}
+
+	for _, hook := range d.refreshHooks {
+		hook(out)
+	}
 
 	return nil
 }
 
+// OnRefresh enregistre fn, appelée avec les artistes après chaque RefreshData réussi.
+// À appeler avant le premier rafraîchissement.
+func (d *DataService) OnRefresh(fn func([]models.Artist)) {
+	d.refreshHooks = append(d.refreshHooks, fn)
+}
+
+// Current retourne le jeu de données courant (jamais nil, à ne pas modifier)
+func (d *DataService) Current() *Dataset {
+	return d.data.Load()
+}
+
+func (d *DataService) publish(ds *Dataset) {
+	d.writeMu.Lock()
+	d.data.Store(ds)
+	d.writeMu.Unlock()
+}
+
+// GetArtists retourne les artistes courants. Le slice est partagé : ne pas le modifier.
 func (d *DataService) GetArtists() []models.Artist {
-	d.App.Mu.RLock()
-	defer d.App.Mu.RUnlock()
-	cp := make([]models.Artist, len(d.App.Artists))
-	copy(cp, d.App.Artists)
-	return cp
+	return d.Current().Artists
 }
 
+// GetLocations retourne les lieux de concert affichables, triés (slice partagé)
+func (d *DataService) GetLocations() []string {
+	return d.Current().Locations
+}
+
+// UpdateArtists remplace les artistes en gardant les lieux et la date du jeu courant
 func (d *DataService) UpdateArtists(artists []models.Artist) {
-	d.App.Mu.Lock()
-	d.App.Artists = artists
-	d.App.Mu.Unlock()
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	current := d.data.Load()
+	d.data.Store(NewDataset(artists, current.Locations, current.UpdatedAt))
 }
 
-func (d *DataService) FindArtistByID(id int) (models.Artist, bool) {
-	d.App.Mu.RLock()
-	defer d.App.Mu.RUnlock()
-	// Recherche binaire ou directe si les IDs sont contigus
-	if id > 0 && id <= len(d.App.Artists) {
-		art := d.App.Artists[id-1]
-		if art.ID == id {
-			return art, true
+// SetCoordinates renseigne les coordonnées des concerts dont le lieu brut (Concert.Location)
+// figure dans coords, puis publie le nouveau jeu de données. Retourne le nombre de concerts modifiés.
+func (d *DataService) SetCoordinates(coords map[string]models.Coordinates) int {
+	d.writeMu.Lock()
+	current := d.data.Load()
+	artists := make([]models.Artist, len(current.Artists))
+	copy(artists, current.Artists)
+
+	updated := 0
+	for i := range artists {
+		art := &artists[i]
+		changed := false
+		for j, c := range art.Concerts {
+			point, ok := coords[c.Location]
+			if !ok || (c.Coordinates != nil && *c.Coordinates == point) {
+				continue
+			}
+			if !changed {
+				// Les concerts et événements publiés sont partagés : copie avant modification
+				art.Concerts = slices.Clone(art.Concerts)
+				art.Events = slices.Clone(art.Events)
+				changed = true
+			}
+			art.Concerts[j].Coordinates = &point
+			updated++
 		}
-	}
-	// Fallback: recherche linéaire
-	for _, art := range d.App.Artists {
-		if art.ID == id {
-			return art, true
+		if !changed {
+			continue
+		}
+		for k, e := range art.Events {
+			if point, ok := coords[e.Location]; ok {
+				art.Events[k].Coordinates = &point
+			}
 		}
 	}
-	return models.Artist{}, false
+	if updated > 0 {
+		d.data.Store(NewDataset(artists, current.Locations, current.UpdatedAt))
+	}
+	d.writeMu.Unlock()
+
+	if updated > 0 {
+		d.scheduleSnapshot()
+	}
+	return updated
 }
 
-func CombineArtist(apiData models.APIArtist, rel models.APIRelation) models.Artist {
+func (d *DataService) FindArtistByID(id int) (models.Artist, bool) {
+	return d.Current().Artist(id)
+}
+
+// CombineArtist construit un artiste à partir des données de l'API et de ses métadonnées locales (optionnelles)
+func CombineArtist(apiData models.APIArtist, rel models.APIRelation, meta *models.ArtistMeta) models.Artist {
 	concerts := make([]models.Concert, 0, len(rel.DatesLocations))
 
 	for raw, dates := range rel.DatesLocations {
@@ -160,7 +305,7 @@ func CombineArtist(apiData models.APIArtist, rel models.APIRelation) models.Arti
 	}
 	sort.Strings(tags)
 
-	return models.Artist{
+	art := models.Artist{
 		ID:             apiData.ID,
 		Name:           apiData.Name,
 		Image:          apiData.Image,
@@ -169,8 +314,11 @@ func CombineArtist(apiData models.APIArtist, rel models.APIRelation) models.Arti
 		FirstAlbum:     apiData.FirstAlbum,
 		FirstAlbumYear: firstAlbumYear,
 		Concerts:       concerts,
+		Events:         BuildEvents(concerts),
 		Tags:           tags,
 	}
+	ApplyMeta(&art, meta)
+	return art
 }
 
 func BuildFilterMeta(artists []models.Artist, locations []string) models.FilterMeta {
@@ -213,11 +361,36 @@ func BuildFilterMeta(artists []models.Artist, locations []string) models.FilterM
 		MembersMin:  membersMin,
 		MembersMax:  membersMax,
 		Locations:   locations,
+		Countries:   countries(artists),
+
+		MemberBuckets:   memberBuckets(membersMin, membersMax),
+		CreationDecades: decadeRanges(artists, artistCreationYear),
+		AlbumDecades:    decadeRanges(artists, artistFirstAlbumYear),
+	}
+}
+
+// countries liste les pays des concerts, triés
+func countries(artists []models.Artist) []string {
+	set := map[string]struct{}{}
+	for _, a := range artists {
+		for _, c := range a.Concerts {
+			set[utils.LocationCountry(c.Location)] = struct{}{}
+		}
+	}
+	out := make([]string, 0, len(set))
+	for c := range set {
+		out = append(out, c)
 	}
+	sort.Strings(out)
+	return out
 }
 
 func ParseFilterCriteria(r *http.Request) models.FilterCriteria {
-	q := r.URL.Query()
+	return FilterCriteriaFromValues(r.URL.Query())
+}
+
+// FilterCriteriaFromValues lit les critères depuis des paramètres de query string
+func FilterCriteriaFromValues(q url.Values) models.FilterCriteria {
 
 	parse := func(key string) (int, bool) {
 		val := strings.TrimSpace(q.Get(key))
@@ -241,65 +414,193 @@ func ParseFilterCriteria(r *http.Request) models.FilterCriteria {
 	locationValues := q["location"]
 	locSet := make(map[string]struct{})
 	for _, loc := range locationValues {
-		if loc == "" {
-			continue
+		if folded := utils.FoldText(loc); folded != "" {
+			locSet[folded] = struct{}{}
+		}
+	}
+
+	countrySet := make(map[string]struct{})
+	for _, c := range q["country"] {
+		if folded := utils.FoldText(c); folded != "" {
+			countrySet[folded] = struct{}{}
 		}
-		locSet[loc] = struct{}{}
+	}
+
+	concertFrom, _ := parseDateParam(q.Get("dateFrom"))
+	concertTo, _ := parseDateParam(q.Get("dateTo"))
+	// Paramètres géographiques invalides ignorés ici ; /api/filter les rejette avec ParseGeoParams
+	center, radiusKm, nearMe, err := ParseGeoParams(q)
+	if err != nil {
+		center, nearMe = nil, false
 	}
 
 	return models.FilterCriteria{
-		CreationMin: creationMin,
-		CreationMax: creationMax,
-		AlbumMin:    albumMin,
-		AlbumMax:    albumMax,
-		MembersMin:  membersMin,
-		MembersMax:  membersMax,
-		Locations:   locSet,
+		CreationMin:   creationMin,
+		CreationMax:   creationMax,
+		AlbumMin:      albumMin,
+		AlbumMax:      albumMax,
+		MembersMin:    membersMin,
+		MembersMax:    membersMax,
+		Locations:     locSet,
+		ConcertFrom:   concertFrom,
+		ConcertTo:     concertTo,
+		Countries:     countrySet,
+		Member:        utils.FoldText(q.Get("member")),
+		UpcomingOnly:  IsChecked(q.Get("upcoming")),
+		FavoritesOnly: IsChecked(q.Get("favorites")),
+		Center:        center,
+		RadiusKm:      radiusKm,
+		NearMe:        nearMe,
+		Now:           time.Now(),
 	}
 }
 
+// parseDateParam accepte le format des champs date HTML (2006-01-02) ou celui de l'API (02-01-2006)
+func parseDateParam(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true
+	}
+	return utils.ParseDate(value)
+}
+
+// IsChecked interprète une case à cocher ou un booléen de query string ("1", "true", "on"...)
+func IsChecked(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "1", "true", "on", "yes", "oui":
+		return true
+	}
+	return false
+}
+
 func FilterArtists(artists []models.Artist, criteria models.FilterCriteria) []models.Artist {
 	result := make([]models.Artist, 0, len(artists)/2) // Pré-allocation estimée
 
 	for _, art := range artists {
-		if criteria.CreationMin != 0 && art.CreationDate < criteria.CreationMin {
-			continue
-		}
-		if criteria.CreationMax != 0 && art.CreationDate > criteria.CreationMax {
-			continue
-		}
-		if criteria.AlbumMin != 0 && art.FirstAlbumYear < criteria.AlbumMin {
-			continue
-		}
-		if criteria.AlbumMax != 0 && art.FirstAlbumYear > criteria.AlbumMax {
-			continue
+		if MatchesCriteria(art, criteria) {
+			result = append(result, art)
 		}
+	}
 
-		memberCount := len(art.Members)
-		if criteria.MembersMin != 0 && memberCount < criteria.MembersMin {
-			continue
+	return result
+}
+
+// MatchesCriteria indique si un artiste respecte tous les critères
+func MatchesCriteria(art models.Artist, criteria models.FilterCriteria) bool {
+	if criteria.CreationMin != 0 && art.CreationDate < criteria.CreationMin {
+		return false
+	}
+	if criteria.CreationMax != 0 && art.CreationDate > criteria.CreationMax {
+		return false
+	}
+	if criteria.AlbumMin != 0 && art.FirstAlbumYear < criteria.AlbumMin {
+		return false
+	}
+	if criteria.AlbumMax != 0 && art.FirstAlbumYear > criteria.AlbumMax {
+		return false
+	}
+
+	memberCount := len(art.Members)
+	if criteria.MembersMin != 0 && memberCount < criteria.MembersMin {
+		return false
+	}
+	if criteria.MembersMax != 0 && memberCount > criteria.MembersMax {
+		return false
+	}
+
+	if criteria.Member != "" && !matchesMember(art, criteria.Member) {
+		return false
+	}
+
+	if criteria.FavoritesOnly {
+		if _, ok := criteria.Favorites[art.ID]; !ok {
+			return false
 		}
-		if criteria.MembersMax != 0 && memberCount > criteria.MembersMax {
-			continue
+	}
+
+	return matchesConcerts(art, criteria)
+}
+
+func matchesMember(art models.Artist, member string) bool {
+	for _, m := range art.Members {
+		if strings.Contains(utils.FoldText(m), member) {
+			return true
 		}
+	}
+	return false
+}
 
-		if len(criteria.Locations) > 0 {
-			if !matchesLocations(art, criteria.Locations) {
-				continue
+// matchesConcerts vérifie qu'un même concert respecte à la fois lieu, pays, rayon, période et « à venir »
+func matchesConcerts(art models.Artist, criteria models.FilterCriteria) bool {
+	if !hasConcertCriteria(criteria) {
+		return true
+	}
+	found := false
+	forEachMatchingConcert(art, criteria, func(location, displayLocation string) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+func hasConcertCriteria(criteria models.FilterCriteria) bool {
+	return len(criteria.Locations) > 0 || len(criteria.Countries) > 0 || criteria.Center != nil ||
+		criteria.UpcomingOnly || !criteria.ConcertFrom.IsZero() || !criteria.ConcertTo.IsZero()
+}
+
+// forEachMatchingConcert appelle fn pour chaque concert de l'artiste qui respecte les critères
+// de concert, jusqu'à ce que fn retourne false. Sans critère de date, un lieu compte une fois.
+func forEachMatchingConcert(art models.Artist, criteria models.FilterCriteria, fn func(location, displayLocation string) bool) {
+	if !criteria.UpcomingOnly && criteria.ConcertFrom.IsZero() && criteria.ConcertTo.IsZero() {
+		for _, concert := range art.Concerts {
+			if matchesPlace(concert.Location, concert.DisplayLocation, concert.Coordinates, criteria) &&
+				!fn(concert.Location, concert.DisplayLocation) {
+				return
 			}
 		}
+		return
+	}
 
-		result = append(result, art)
+	now := criteria.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, e := range art.Events {
+		if matchesEvent(e, criteria, now) && !fn(e.Location, e.DisplayLocation) {
+			return
+		}
 	}
+}
 
-	return result
+// matchesEvent vérifie un concert daté contre tous les critères de concert
+func matchesEvent(e models.ConcertEvent, criteria models.FilterCriteria, now time.Time) bool {
+	if !criteria.ConcertFrom.IsZero() && e.Date.Before(criteria.ConcertFrom) {
+		return false
+	}
+	// Borne de fin incluse : toute la journée compte
+	if !criteria.ConcertTo.IsZero() && !e.Date.Before(criteria.ConcertTo.AddDate(0, 0, 1)) {
+		return false
+	}
+	if criteria.UpcomingOnly && !IsUpcoming(e, now) {
+		return false
+	}
+	return matchesPlace(e.Location, e.DisplayLocation, e.Coordinates, criteria)
 }
 
-func matchesLocations(art models.Artist, wanted map[string]struct{}) bool {
-	for _, concert := range art.Concerts {
-		if _, ok := wanted[concert.DisplayLocation]; ok {
-			return true
+func matchesPlace(location, displayLocation string, coords *models.Coordinates, criteria models.FilterCriteria) bool {
+	if len(criteria.Locations) > 0 {
+		_, ok := criteria.Locations[utils.FoldText(displayLocation)]
+		if !ok {
+			_, ok = criteria.Locations[utils.FoldText(location)]
+		}
+		if !ok {
+			return false
 		}
 	}
-	return false
+	if len(criteria.Countries) > 0 {
+		if _, ok := criteria.Countries[utils.FoldText(utils.LocationCountry(location))]; !ok {
+			return false
+		}
+	}
+	return withinRadius(coords, criteria)
 }
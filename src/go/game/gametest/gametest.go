@@ -0,0 +1,32 @@
+// Package gametest fournit des jeux de données pour les tests des handlers
+package gametest
+
+import (
+	"context"
+	"testing"
+
+	"groupie/src/go/api"
+	"groupie/src/go/game"
+	"groupie/src/go/models"
+)
+
+// NewDataService charge le jeu d'essai embarqué puis publie n artistes qui en sont
+// dupliqués, d'identifiants 1 à n (n <= 0 : le jeu d'essai tel quel)
+func NewDataService(tb testing.TB, n int) *game.DataService {
+	tb.Helper()
+	d := game.NewDataService(&models.App{}, api.NewFixtureSource(), "", "")
+	if err := d.RefreshData(context.Background()); err != nil {
+		tb.Fatal(err)
+	}
+	if n <= 0 {
+		return d
+	}
+	base := d.GetArtists()
+	artists := make([]models.Artist, n)
+	for i := range artists {
+		artists[i] = base[i%len(base)]
+		artists[i].ID = i + 1
+	}
+	d.UpdateArtists(artists)
+	return d
+}
@@ -0,0 +1,281 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+// LocationResolver résout un lieu en coordonnées (voir api.GeocodeService)
+type LocationResolver interface {
+	LookupCoordinates(ctx context.Context, location string) (*models.Coordinates, error)
+	// CachedCoordinates ne fait aucun appel réseau ; api.ErrNotCached si seul le réseau peut répondre
+	CachedCoordinates(location string) (*models.Coordinates, error)
+}
+
+// CachedConcert cherche dans les caches le lieu affiché d'un concert, puis son lieu brut ;
+// api.ErrNotCached si seul le réseau peut répondre
+func CachedConcert(resolver LocationResolver, c models.Concert) (*models.Coordinates, error) {
+	result := api.ErrNoCoordinates
+	for _, name := range []string{c.DisplayLocation, strings.ReplaceAll(c.Location, "-", " ")} {
+		coords, err := resolver.CachedCoordinates(name)
+		if err == nil {
+			return coords, nil
+		}
+		if errors.Is(err, api.ErrNotCached) {
+			result = err
+		}
+	}
+	return nil, result
+}
+
+// GeocodeQueue géocode en arrière-plan les lieux de concert sans coordonnées et reporte
+// les résultats dans le jeu de données. Les lieux déjà en cache sont résolus aussitôt ;
+// les autres partent vers Nominatim au plus une fois par Interval.
+type GeocodeQueue struct {
+	Data     *DataService
+	Resolver LocationResolver
+	Interval time.Duration
+
+	mu        sync.Mutex
+	pending   []queuedLocation
+	queued    map[string]bool               // lieux bruts en attente ou en cours
+	submitted map[string]models.Coordinates // résolus ailleurs, publiés au prochain lot
+	progress  models.GeocodeProgress
+	wake      chan struct{}
+	lastCall  time.Time
+}
+
+type queuedLocation struct {
+	location string // Concert.Location, clé de SetCoordinates
+	display  string
+}
+
+func NewGeocodeQueue(data *DataService, resolver LocationResolver) *GeocodeQueue {
+	return &GeocodeQueue{
+		Data:      data,
+		Resolver:  resolver,
+		Interval:  config.GeocodeQueueInterval,
+		queued:    make(map[string]bool),
+		submitted: make(map[string]models.Coordinates),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// EnqueueMissing met en file les lieux des concerts sans coordonnées.
+// Sa signature permet de l'enregistrer avec DataService.OnRefresh.
+func (q *GeocodeQueue) EnqueueMissing(artists []models.Artist) {
+	var missing []models.Concert
+	for _, art := range artists {
+		for _, c := range art.Concerts {
+			if c.Coordinates == nil {
+				missing = append(missing, c)
+			}
+		}
+	}
+	q.Enqueue(missing)
+}
+
+// Enqueue met en file les lieux des concerts ; un lieu déjà en attente ou en cours n'est
+// pas ajouté une seconde fois
+func (q *GeocodeQueue) Enqueue(concerts []models.Concert) {
+	q.mu.Lock()
+	added := 0
+	for _, c := range concerts {
+		if q.queued[c.Location] {
+			continue
+		}
+		q.queued[c.Location] = true
+		q.pending = append(q.pending, queuedLocation{location: c.Location, display: c.DisplayLocation})
+		added++
+	}
+	q.progress.Queued += added
+	q.mu.Unlock()
+
+	if added > 0 {
+		q.signal()
+	}
+}
+
+// Submit confie à la file des coordonnées résolues ailleurs (page artiste) : elles sont
+// publiées avec le prochain lot, aussitôt si la file est inactive
+func (q *GeocodeQueue) Submit(coords map[string]models.Coordinates) {
+	if len(coords) == 0 {
+		return
+	}
+	q.mu.Lock()
+	maps.Copy(q.submitted, coords)
+	q.mu.Unlock()
+	q.signal()
+}
+
+func (q *GeocodeQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Progress retourne l'avancement de la file
+func (q *GeocodeQueue) Progress() models.GeocodeProgress {
+	q.mu.Lock()
+	p := q.progress
+	p.Pending = len(q.pending)
+	q.mu.Unlock()
+
+	for _, art := range q.Data.GetArtists() {
+		for _, c := range art.Concerts {
+			if c.Coordinates == nil {
+				p.Missing++
+			}
+		}
+	}
+	return p
+}
+
+// Start lance le worker jusqu'à l'annulation de ctx
+func (q *GeocodeQueue) Start(ctx context.Context) {
+	go q.run(ctx)
+}
+
+func (q *GeocodeQueue) run(ctx context.Context) {
+	batch := map[string]models.Coordinates{}
+	lastFlush := time.Now()
+	flush := func() {
+		q.mu.Lock()
+		maps.Copy(batch, q.submitted)
+		clear(q.submitted)
+		q.mu.Unlock()
+		if len(batch) > 0 {
+			n := q.Data.SetCoordinates(batch)
+			log.Printf("géocodage: %d lieux résolus, %d concerts mis à jour", len(batch), n)
+			batch = map[string]models.Coordinates{}
+		}
+		lastFlush = time.Now()
+	}
+
+	for {
+		item, ok := q.next()
+		if !ok {
+			flush()
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+				continue
+			}
+		}
+
+		coords, err := q.resolve(ctx, item)
+		switch {
+		case err == nil:
+			batch[item.location] = *coords
+			q.done(item, true)
+		case ctx.Err() != nil:
+			flush()
+			return
+		case errors.Is(err, api.ErrRateLimited):
+			// Les pages ont consommé le débit : le lieu repasse en fin de file
+			q.requeue(item)
+			var limited *api.RateLimitError
+			if errors.As(err, &limited) && !q.sleep(ctx, limited.RetryAfter) {
+				flush()
+				return
+			}
+		default:
+			q.done(item, false)
+		}
+
+		if len(batch) >= config.GeocodeQueueBatch || time.Since(lastFlush) >= config.GeocodeQueueFlushInterval {
+			flush()
+		}
+	}
+}
+
+func (q *GeocodeQueue) next() (queuedLocation, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		q.progress.Current = ""
+		return queuedLocation{}, false
+	}
+	item := q.pending[0]
+	q.pending = q.pending[1:]
+	q.progress.Current = item.display
+	return item, true
+}
+
+func (q *GeocodeQueue) done(item queuedLocation, resolved bool) {
+	q.mu.Lock()
+	delete(q.queued, item.location)
+	if resolved {
+		q.progress.Resolved++
+	} else {
+		q.progress.Failed++
+	}
+	q.mu.Unlock()
+}
+
+func (q *GeocodeQueue) requeue(item queuedLocation) {
+	q.mu.Lock()
+	q.pending = append(q.pending, item)
+	q.mu.Unlock()
+}
+
+// resolve essaie le lieu affiché puis le lieu brut, d'abord dans les caches,
+// puis sur le réseau au rythme de la file
+func (q *GeocodeQueue) resolve(ctx context.Context, item queuedLocation) (*models.Coordinates, error) {
+	names := []string{item.display, strings.ReplaceAll(item.location, "-", " ")}
+
+	var uncached []string
+	for _, name := range names {
+		coords, err := q.Resolver.CachedCoordinates(name)
+		if err == nil {
+			return coords, nil
+		}
+		if errors.Is(err, api.ErrNotCached) {
+			uncached = append(uncached, name)
+		}
+	}
+	if len(uncached) == 0 {
+		return nil, api.ErrNoCoordinates
+	}
+
+	var err error
+	for _, name := range uncached {
+		if !q.sleep(ctx, time.Until(q.lastCall.Add(q.Interval))) {
+			return nil, ctx.Err()
+		}
+		q.lastCall = time.Now()
+
+		var coords *models.Coordinates
+		coords, err = q.Resolver.LookupCoordinates(ctx, name)
+		if err == nil || errors.Is(err, api.ErrRateLimited) {
+			return coords, err
+		}
+	}
+	return nil, err
+}
+
+// sleep attend d, ou retourne false si ctx est annulé avant
+func (q *GeocodeQueue) sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
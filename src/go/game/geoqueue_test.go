@@ -0,0 +1,45 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/models"
+)
+
+// cacheOnlyResolver ne connaît aucun lieu, sans appel réseau
+type cacheOnlyResolver struct{}
+
+func (cacheOnlyResolver) LookupCoordinates(ctx context.Context, location string) (*models.Coordinates, error) {
+	return nil, api.ErrNoCoordinates
+}
+
+func (cacheOnlyResolver) CachedCoordinates(location string) (*models.Coordinates, error) {
+	return nil, api.ErrNoCoordinates
+}
+
+func TestGeocodeQueueSubmit(t *testing.T) {
+	d := newTestService(t)
+	location := d.GetArtists()[0].Concerts[0].Location
+	q := NewGeocodeQueue(d, cacheOnlyResolver{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q.Start(ctx)
+
+	want := models.Coordinates{Latitude: 12, Longitude: 34}
+	q.Submit(map[string]models.Coordinates{location: want})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c := d.GetArtists()[0].Concerts[0]
+		if c.Coordinates != nil && *c.Coordinates == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("coordonnées soumises non publiées: %v", c.Coordinates)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+const (
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// StartRefresh lance un rafraîchissement en arrière-plan et retourne le job créé.
+// Si un rafraîchissement est déjà en cours, ce job est retourné à la place.
+func (d *DataService) StartRefresh(trigger string) models.RefreshJob {
+	d.jobsMu.Lock()
+	defer d.jobsMu.Unlock()
+
+	if d.currentJob != nil {
+		return *d.currentJob
+	}
+
+	job := &models.RefreshJob{
+		ID:        newJobID(),
+		Trigger:   trigger,
+		Status:    JobRunning,
+		StartedAt: time.Now(),
+	}
+	if d.jobs == nil {
+		d.jobs = make(map[string]*models.RefreshJob)
+	}
+	d.jobs[job.ID] = job
+	d.jobOrder = append(d.jobOrder, job.ID)
+	if len(d.jobOrder) > config.MaxRefreshJobs {
+		delete(d.jobs, d.jobOrder[0])
+		d.jobOrder = d.jobOrder[1:]
+	}
+	d.currentJob = job
+
+	go d.runJob(job)
+	return *job
+}
+
+func (d *DataService) runJob(job *models.RefreshJob) {
+	ctx, cancel := context.WithTimeout(context.Background(), config.RefreshTimeout)
+	defer cancel()
+
+	err := d.RefreshData(ctx)
+	report := d.LastReport()
+	// L'API a demandé d'attendre plus que le client ne le pouvait : nouvel essai planifié
+	if report.RetryAfterMs > 0 {
+		time.AfterFunc(time.Duration(report.RetryAfterMs)*time.Millisecond, func() { d.StartRefresh("retry") })
+	}
+
+	d.jobsMu.Lock()
+	defer d.jobsMu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Report = &report
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+		log.Printf("refresh %s (%s): %v", job.ID, job.Trigger, err)
+	} else {
+		job.Status = JobDone
+	}
+	d.currentJob = nil
+}
+
+// setProgress met à jour l'avancement du job en cours
+func (d *DataService) setProgress(done, total int) {
+	d.jobsMu.Lock()
+	defer d.jobsMu.Unlock()
+	if d.currentJob != nil {
+		// Les workers peuvent terminer dans le désordre: l'avancement ne recule jamais
+		if done > d.currentJob.Done {
+			d.currentJob.Done = done
+		}
+		d.currentJob.Total = total
+	}
+}
+
+// GetJob retourne l'état d'un job de rafraîchissement
+func (d *DataService) GetJob(id string) (models.RefreshJob, bool) {
+	d.jobsMu.Lock()
+	defer d.jobsMu.Unlock()
+	job, ok := d.jobs[id]
+	if !ok {
+		return models.RefreshJob{}, false
+	}
+	return *job, true
+}
+
+// StartScheduler rafraîchit les données à intervalle régulier jusqu'à l'annulation du contexte
+func (d *DataService) StartScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.StartRefresh("schedule")
+			}
+		}
+	}()
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
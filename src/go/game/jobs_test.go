@@ -0,0 +1,91 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/models"
+)
+
+// blockingSource retient FetchIndex jusqu'à la fermeture de release, puis échoue avec err
+type blockingSource struct {
+	api.DataSource
+	release chan struct{}
+	err     error
+}
+
+func (s *blockingSource) FetchIndex(ctx context.Context) (*models.APIIndex, error) {
+	<-s.release
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.DataSource.FetchIndex(ctx)
+}
+
+// waitJob attend la fin d'un job
+func waitJob(t *testing.T, d *DataService, id string) models.RefreshJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, ok := d.GetJob(id)
+		if !ok {
+			t.Fatalf("job %s introuvable", id)
+		}
+		if job.Status != JobRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s toujours en cours", id)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStartRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		finishFirst bool // le premier job se termine avant le second appel
+		err         error
+		sameJob     bool
+		status      string
+	}{
+		{"appel pendant un job : rejoint", false, nil, true, JobDone},
+		{"appel après la fin : nouveau job", true, nil, false, JobDone},
+		{"job en échec", true, errors.New("index indisponible"), false, JobFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newTestService(t)
+			source := &blockingSource{DataSource: d.Source, release: make(chan struct{}), err: tt.err}
+			d.Source = source
+
+			first := d.StartRefresh("manual")
+			if first.Status != JobRunning {
+				t.Fatalf("premier job: statut %q", first.Status)
+			}
+			if tt.finishFirst {
+				close(source.release)
+				if job := waitJob(t, d, first.ID); job.Status != tt.status {
+					t.Errorf("premier job: statut %q, attendu %q (%s)", job.Status, tt.status, job.Error)
+				}
+			}
+
+			second := d.StartRefresh("schedule")
+			if (second.ID == first.ID) != tt.sameJob {
+				t.Errorf("second appel: job %s, premier %s ; même job attendu : %v", second.ID, first.ID, tt.sameJob)
+			}
+			if tt.sameJob && second.Trigger != "manual" {
+				t.Errorf("job rejoint: déclencheur %q, attendu celui du premier appel", second.Trigger)
+			}
+			if !tt.finishFirst {
+				close(source.release)
+			}
+			if job := waitJob(t, d, second.ID); job.Status != tt.status || job.Report == nil {
+				t.Errorf("second job: statut %q, rapport %v ; attendu %q", job.Status, job.Report, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package game
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+// Clés de tri des listes d'artistes
+const (
+	SortName           = "name"
+	SortCreationDate   = "creationDate"
+	SortFirstAlbumYear = "firstAlbumYear"
+	SortMemberCount    = "memberCount"
+	SortConcertCount   = "concertCount"
+	SortNextConcert    = "nextConcert"
+)
+
+var sortKeys = map[string]bool{
+	SortName:           true,
+	SortCreationDate:   true,
+	SortFirstAlbumYear: true,
+	SortMemberCount:    true,
+	SortConcertCount:   true,
+	SortNextConcert:    true,
+}
+
+// listParams sont les paramètres qui activent la réponse paginée
+var listParams = []string{"sort", "order", "offset", "limit", "cursor", "fields"}
+
+// artistFields liste les noms JSON des champs d'un artiste, pour ?fields=
+var artistFields = func() map[string]bool {
+	fields := map[string]bool{}
+	t := reflect.TypeOf(models.Artist{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// ParseListOptions lit sort, order, offset, limit, cursor et fields.
+// ok est faux quand aucun de ces paramètres n'est fourni.
+func ParseListOptions(q url.Values) (opts models.ListOptions, ok bool, err error) {
+	for _, p := range listParams {
+		if _, present := q[p]; present {
+			ok = true
+		}
+	}
+	if !ok {
+		return opts, false, nil
+	}
+
+	opts.Sort = SortName
+	if s := strings.TrimSpace(q.Get("sort")); s != "" {
+		if !sortKeys[s] {
+			return opts, true, fmt.Errorf("paramètre 'sort' invalide: %q", s)
+		}
+		opts.Sort = s
+	}
+
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		opts.Desc = true
+	default:
+		return opts, true, fmt.Errorf("paramètre 'order' invalide")
+	}
+
+	opts.Limit = config.DefaultPageSize
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > config.MaxPageSize {
+			return opts, true, fmt.Errorf("paramètre 'limit' invalide (1 à %d)", config.MaxPageSize)
+		}
+		opts.Limit = n
+	}
+
+	if raw := q.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return opts, true, fmt.Errorf("paramètre 'offset' invalide")
+		}
+		opts.Offset = n
+	}
+
+	opts.Cursor = q.Get("cursor")
+	if opts.Cursor != "" && opts.Offset > 0 {
+		return opts, true, fmt.Errorf("'cursor' et 'offset' sont incompatibles")
+	}
+
+	for _, raw := range q["fields"] {
+		for _, f := range strings.Split(raw, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			if !artistFields[f] {
+				return opts, true, fmt.Errorf("champ inconnu: %q", f)
+			}
+			opts.Fields = append(opts.Fields, f)
+		}
+	}
+
+	return opts, true, nil
+}
+
+// listKey est la clé de tri d'un artiste ; elle sert aussi de curseur
+type listKey struct {
+	None bool   `json:"x,omitempty"` // sans prochain concert : toujours en fin de liste
+	Num  int64  `json:"n,omitempty"`
+	Name string `json:"s"`
+	ID   int    `json:"id"`
+}
+
+type listCursor struct {
+	Sort string  `json:"sort"`
+	Desc bool    `json:"desc,omitempty"`
+	Key  listKey `json:"key"`
+}
+
+func artistListKey(art models.Artist, sortBy string, now time.Time) listKey {
+	key := listKey{Name: strings.ToLower(art.Name), ID: art.ID}
+	switch sortBy {
+	case SortCreationDate:
+		key.Num = int64(art.CreationDate)
+	case SortFirstAlbumYear:
+		key.Num = int64(art.FirstAlbumYear)
+	case SortMemberCount:
+		key.Num = int64(len(art.Members))
+	case SortConcertCount:
+		key.Num = int64(len(art.Events))
+	case SortNextConcert:
+		if e, ok := NextEvent(art, now); ok {
+			key.Num = e.Date.Unix()
+		} else {
+			key.None = true
+		}
+	}
+	return key
+}
+
+// compareListKeys ordonne par valeur, puis nom, puis ID ; desc inverse le tout
+func compareListKeys(a, b listKey, desc bool) int {
+	if a.None != b.None {
+		if a.None {
+			return 1
+		}
+		return -1
+	}
+	var c int
+	switch {
+	case a.Num != b.Num:
+		c = cmp.Compare(a.Num, b.Num)
+	case a.Name != b.Name:
+		c = strings.Compare(a.Name, b.Name)
+	default:
+		c = cmp.Compare(a.ID, b.ID)
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// listOrder est l'ordre d'un jeu de données pour un tri, calculé une fois : keys est indexé
+// par position dans Dataset.Artists, order donne les positions dans l'ordre croissant et les
+// artistes sans valeur (pas de prochain concert) occupent la fin, à partir de none.
+type listOrder struct {
+	keys  []listKey
+	order []int
+	none  int
+}
+
+func newListOrder(artists []models.Artist, names []string, sortBy string, now time.Time) *listOrder {
+	o := &listOrder{keys: make([]listKey, len(artists)), order: make([]int, len(artists))}
+	for pos, art := range artists {
+		o.keys[pos] = artistListKey(art, sortBy, now)
+		o.keys[pos].Name = names[pos]
+		o.order[pos] = pos
+	}
+	sort.Slice(o.order, func(a, b int) bool {
+		return compareListKeys(o.keys[o.order[a]], o.keys[o.order[b]], false) < 0
+	})
+	o.none = sort.Search(len(o.order), func(k int) bool { return o.keys[o.order[k]].None })
+	return o
+}
+
+// at retourne la position du k-ième artiste ; en ordre décroissant, les artistes avec
+// et sans valeur sont parcourus chacun à l'envers, ceux sans valeur restant à la fin
+func (o *listOrder) at(k int, desc bool) int {
+	switch {
+	case !desc:
+		return o.order[k]
+	case k < o.none:
+		return o.order[o.none-1-k]
+	default:
+		return o.order[len(o.order)-1-(k-o.none)]
+	}
+}
+
+func encodeCursor(c listCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(raw string) (listCursor, error) {
+	var c listCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil {
+		return c, fmt.Errorf("curseur invalide")
+	}
+	return c, nil
+}
+
+// List trie, pagine et réduit artists comme ListArtists, en reprenant l'ordre précalculé
+// du jeu de données : seule la page est allouée. artists doit être ds.Artists ou une partie
+// de ses artistes (résultat de Filter) ; sinon ListArtists est utilisé.
+func (ds *Dataset) List(artists []models.Artist, opts models.ListOptions, now time.Time) (models.ArtistPage, error) {
+	o := ds.listOrder(opts.Sort, now)
+	if o == nil {
+		return ListArtists(artists, opts, now)
+	}
+
+	// member retourne l'indice dans artists de l'artiste en position pos, -1 s'il n'y est pas
+	member := func(pos int) int { return pos }
+	if len(artists) != len(ds.Artists) || (len(artists) > 0 && &artists[0] != &ds.Artists[0]) {
+		index := make([]int32, len(ds.Artists)) // indice + 1, 0 : absent
+		for i, art := range artists {
+			pos, ok := ds.byID[art.ID]
+			if !ok {
+				return ListArtists(artists, opts, now)
+			}
+			index[pos] = int32(i + 1)
+		}
+		member = func(pos int) int { return int(index[pos]) - 1 }
+	}
+
+	first := 0 // premier indice de l'ordre après le curseur
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return models.ArtistPage{}, err
+		}
+		if cursor.Sort != opts.Sort || cursor.Desc != opts.Desc {
+			return models.ArtistPage{}, fmt.Errorf("curseur obtenu avec un autre tri")
+		}
+		first = sort.Search(len(o.order), func(k int) bool {
+			return compareListKeys(o.keys[o.at(k, opts.Desc)], cursor.Key, opts.Desc) > 0
+		})
+	}
+
+	start, skip := 0, opts.Offset
+	page := make([]models.Artist, 0, min(opts.Limit, len(artists)))
+	last := -1
+	for k := 0; k < len(o.order) && len(page) < opts.Limit; k++ {
+		pos := o.at(k, opts.Desc)
+		i := member(pos)
+		if i < 0 {
+			continue
+		}
+		if k < first {
+			start++
+			continue
+		}
+		if skip > 0 {
+			start++
+			skip--
+			continue
+		}
+		page = append(page, artists[i])
+		last = pos
+	}
+	return pageOf(page, len(artists), start, opts, func() listKey { return o.keys[last] })
+}
+
+// listOrder retourne l'ordre précalculé d'un tri ; celui des prochains concerts dépend
+// du jour et est recalculé au changement de date
+func (ds *Dataset) listOrder(sortBy string, now time.Time) *listOrder {
+	if o, ok := ds.orders[sortBy]; ok {
+		return o
+	}
+	if sortBy != SortNextConcert {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	ds.nextMu.Lock()
+	defer ds.nextMu.Unlock()
+	if ds.next == nil || !ds.nextDay.Equal(today) {
+		ds.next, ds.nextDay = newListOrder(ds.Artists, ds.names, SortNextConcert, now), today
+	}
+	return ds.next
+}
+
+// ListArtists trie, pagine et réduit une liste d'artistes.
+// Le curseur reprend après le dernier artiste de la page précédente, même si la liste a changé entre-temps.
+func ListArtists(artists []models.Artist, opts models.ListOptions, now time.Time) (models.ArtistPage, error) {
+	keys := make([]listKey, len(artists))
+	order := make([]int, len(artists))
+	for i, art := range artists {
+		keys[i] = artistListKey(art, opts.Sort, now)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return compareListKeys(keys[order[a]], keys[order[b]], opts.Desc) < 0
+	})
+
+	start := opts.Offset
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor)
+		if err != nil {
+			return models.ArtistPage{}, err
+		}
+		if cursor.Sort != opts.Sort || cursor.Desc != opts.Desc {
+			return models.ArtistPage{}, fmt.Errorf("curseur obtenu avec un autre tri")
+		}
+		start = len(order)
+		for pos, i := range order {
+			if compareListKeys(keys[i], cursor.Key, opts.Desc) > 0 {
+				start = pos
+				break
+			}
+		}
+	}
+	start = min(start, len(order))
+	end := min(start+opts.Limit, len(order))
+
+	page := make([]models.Artist, 0, end-start)
+	for _, i := range order[start:end] {
+		page = append(page, artists[i])
+	}
+	return pageOf(page, len(artists), start, opts, func() listKey { return keys[order[end-1]] })
+}
+
+// pageOf assemble une page commençant au rang start ; lastKey donne la clé de son dernier
+// artiste, qui sert de curseur s'il reste des artistes après la page
+func pageOf(page []models.Artist, total, start int, opts models.ListOptions, lastKey func() listKey) (models.ArtistPage, error) {
+	result := models.ArtistPage{
+		Items:  page,
+		Total:  total,
+		Offset: start,
+		Limit:  opts.Limit,
+	}
+	if end := start + len(page); end < total && end > start {
+		result.NextCursor = encodeCursor(listCursor{Sort: opts.Sort, Desc: opts.Desc, Key: lastKey()})
+	}
+	if len(opts.Fields) > 0 {
+		items, err := selectFields(page, opts.Fields)
+		if err != nil {
+			return models.ArtistPage{}, err
+		}
+		result.Items = items
+	}
+	return result, nil
+}
+
+// selectFields réduit chaque artiste aux champs demandés ; l'ID est toujours inclus
+func selectFields(artists []models.Artist, fields []string) ([]map[string]json.RawMessage, error) {
+	out := make([]map[string]json.RawMessage, 0, len(artists))
+	for _, art := range artists {
+		data, err := json.Marshal(art)
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+		item := map[string]json.RawMessage{"id": all["id"]}
+		for _, f := range fields {
+			if v, ok := all[f]; ok {
+				item[f] = v
+			}
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
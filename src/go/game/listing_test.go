@@ -0,0 +1,215 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// listArtists ajoute aux artistes synthétiques des concerts datés autour de now
+func listArtists(n int, now time.Time) []models.Artist {
+	artists := syntheticArtists(n)
+	for i := range artists {
+		if i%3 == 0 {
+			continue // sans prochain concert
+		}
+		artists[i].Events = []models.ConcertEvent{{Date: now.AddDate(0, 0, i%40-10)}}
+	}
+	return artists
+}
+
+func pageIDs(t *testing.T, page models.ArtistPage) []int {
+	t.Helper()
+	items, ok := page.Items.([]models.Artist)
+	if !ok {
+		t.Fatalf("Items de type %T", page.Items)
+	}
+	ids := make([]int, len(items))
+	for i, art := range items {
+		ids[i] = art.ID
+	}
+	return ids
+}
+
+// TestDatasetList compare l'ordre précalculé à ListArtists, page par page, pour chaque tri
+func TestDatasetList(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewDataset(listArtists(200, now), nil, now)
+	var subset []models.Artist
+	for i, art := range ds.Artists {
+		if i%3 != 1 {
+			subset = append(subset, art)
+		}
+	}
+
+	for sortBy := range sortKeys {
+		for _, desc := range []bool{false, true} {
+			for name, artists := range map[string][]models.Artist{"tous": ds.Artists, "partie": subset} {
+				t.Run(fmt.Sprintf("%s/desc=%v/%s", sortBy, desc, name), func(t *testing.T) {
+					opts := models.ListOptions{Sort: sortBy, Desc: desc, Limit: 17}
+					for pages := 0; ; pages++ {
+						want, err := ListArtists(artists, opts, now)
+						if err != nil {
+							t.Fatal(err)
+						}
+						got, err := ds.List(artists, opts, now)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if fmt.Sprint(pageIDs(t, got)) != fmt.Sprint(pageIDs(t, want)) || got.Offset != want.Offset ||
+							got.Total != want.Total || got.NextCursor != want.NextCursor {
+							t.Fatalf("page %d: %v (offset %d), attendu %v (offset %d)", pages, pageIDs(t, got), got.Offset, pageIDs(t, want), want.Offset)
+						}
+						if got.NextCursor == "" {
+							return
+						}
+						opts.Cursor = got.NextCursor
+					}
+				})
+			}
+		}
+	}
+
+	opts := models.ListOptions{Sort: SortCreationDate, Offset: 150, Limit: 20}
+	want, _ := ListArtists(subset, opts, now)
+	got, _ := ds.List(subset, opts, now)
+	if fmt.Sprint(pageIDs(t, got)) != fmt.Sprint(pageIDs(t, want)) || got.Offset != want.Offset {
+		t.Errorf("offset : %v, attendu %v", pageIDs(t, got), pageIDs(t, want))
+	}
+}
+
+// TestDatasetListAllocs vérifie qu'une page ne coûte pas plus d'allocations avec 10 000 artistes qu'avec 100
+func TestDatasetListAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("jeu de 10 000 artistes")
+	}
+	now := time.Now()
+	allocs := func(n int, sortBy string, subset bool) float64 {
+		ds := NewDataset(listArtists(n, now), nil, now)
+		artists := ds.Artists
+		if subset {
+			artists = ds.Filter(models.FilterCriteria{Locations: map[string]struct{}{"paris france": {}}})
+		}
+		opts := models.ListOptions{Sort: sortBy, Limit: 5}
+		return testing.AllocsPerRun(20, func() {
+			if _, err := ds.List(artists, opts, now); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	for _, sortBy := range []string{SortName, SortNextConcert} {
+		for _, subset := range []bool{false, true} {
+			small, large := allocs(100, sortBy, subset), allocs(10000, sortBy, subset)
+			// Quelques allocations d'écart tolérées (curseur plus long), pas une par artiste
+			if large > small+2 {
+				t.Errorf("tri %s (partie: %v) : %.0f allocations pour 10 000 artistes, %.0f pour 100", sortBy, subset, large, small)
+			}
+		}
+	}
+}
+
+// TestDatasetFilterMatchesFilterArtists vérifie que les index donnent le même résultat qu'un parcours complet
+func TestDatasetFilterMatchesFilterArtists(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	ds := NewDataset(listArtists(300, now), nil, now)
+	tests := map[string]models.FilterCriteria{
+		"lieu":           {Locations: map[string]struct{}{"paris france": {}, "tokyo japan": {}}},
+		"pays":           {Countries: map[string]struct{}{"france": {}}},
+		"lieu et pays":   {Locations: map[string]struct{}{"paris france": {}}, Countries: map[string]struct{}{"japan": {}}},
+		"lieu et date":   {Locations: map[string]struct{}{"lyon france": {}}, UpcomingOnly: true, Now: now},
+		"membre":         {Member: "ka"},
+		"membre et lieu": {Member: "ka", Locations: map[string]struct{}{"berlin germany": {}}},
+	}
+	for name, criteria := range tests {
+		got, want := ds.Filter(criteria), FilterArtists(ds.Artists, criteria)
+		if len(got) != len(want) {
+			t.Errorf("%s : %d artistes, attendu %d", name, len(got), len(want))
+			continue
+		}
+		for i := range got {
+			if got[i].ID != want[i].ID {
+				t.Errorf("%s : artiste %d = %d, attendu %d", name, i, got[i].ID, want[i].ID)
+				break
+			}
+		}
+	}
+}
+
+// TestListCursorAcrossRefresh vérifie qu'un curseur obtenu avant un rafraîchissement reprend
+// juste après le dernier artiste vu : les artistes inchangés ne sont ni répétés ni sautés
+func TestListCursorAcrossRefresh(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	// Dates de création 1950 à 1999 : la première page contient les artistes 1 à 10
+	base := syntheticArtists(50)
+	ids := func(from, to int, extra ...int) []int {
+		var out []int
+		for id := from; id <= to; id++ {
+			out = append(out, id)
+		}
+		return append(out, extra...)
+	}
+	without := func(list []int, id int) []int {
+		return slices.DeleteFunc(slices.Clone(list), func(v int) bool { return v == id })
+	}
+	added := func(creation int) func([]models.Artist) []models.Artist {
+		return func(artists []models.Artist) []models.Artist {
+			return append(artists, models.Artist{ID: 100, Name: "nouveau", CreationDate: creation})
+		}
+	}
+	removed := func(id int) func([]models.Artist) []models.Artist {
+		return func(artists []models.Artist) []models.Artist {
+			return slices.DeleteFunc(artists, func(a models.Artist) bool { return a.ID == id })
+		}
+	}
+	moved := func(id, creation int) func([]models.Artist) []models.Artist {
+		return func(artists []models.Artist) []models.Artist {
+			artists[id-1].CreationDate = creation
+			return artists
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func([]models.Artist) []models.Artist
+		want   []int // artistes des pages suivantes
+	}{
+		{"inchangé", func(a []models.Artist) []models.Artist { return a }, ids(11, 50)},
+		{"ajout avant le curseur", added(1940), ids(11, 50)},
+		{"ajout après le curseur", added(2010), ids(11, 50, 100)},
+		{"artiste du curseur retiré", removed(10), ids(11, 50)},
+		{"artiste suivant retiré", removed(11), ids(12, 50)},
+		{"artiste déplacé avant le curseur", moved(30, 1900), without(ids(11, 50), 30)},
+		// Sa clé a changé : il est revu à sa nouvelle place
+		{"artiste vu déplacé après le curseur", moved(5, 2020), ids(11, 50, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := models.ListOptions{Sort: SortCreationDate, Limit: 10}
+			before := NewDataset(slices.Clone(base), nil, now)
+			first, err := before.List(before.Artists, opts, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := pageIDs(t, first); !slices.Equal(got, ids(1, 10)) {
+				t.Fatalf("première page %v", got)
+			}
+
+			refreshed := NewDataset(tt.mutate(slices.Clone(base)), nil, now)
+			var got []int
+			for opts.Cursor = first.NextCursor; opts.Cursor != ""; {
+				page, err := refreshed.List(refreshed.Artists, opts, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, pageIDs(t, page)...)
+				opts.Cursor = page.NextCursor
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pages suivantes %v, attendu %v", got, tt.want)
+			}
+		})
+	}
+}
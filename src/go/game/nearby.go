@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/geo"
+	"groupie/src/go/models"
+)
+
+// ParseGeoParams lit lat, lng, radiusKm et near=me.
+// Le rayon vaut config.DefaultRadiusKm s'il est absent ; lat et lng vont ensemble.
+func ParseGeoParams(q url.Values) (center *models.Coordinates, radiusKm float64, nearMe bool, err error) {
+	parse := func(key string, limit float64) (float64, bool, error) {
+		raw := strings.TrimSpace(q.Get(key))
+		if raw == "" {
+			return 0, false, nil
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || math.IsNaN(v) || math.Abs(v) > limit {
+			return 0, false, fmt.Errorf("paramètre '%s' invalide", key)
+		}
+		return v, true, nil
+	}
+
+	lat, hasLat, err := parse("lat", 90)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	lng, hasLng, err := parse("lng", 180)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if hasLat != hasLng {
+		return nil, 0, false, fmt.Errorf("'lat' et 'lng' doivent être fournis ensemble")
+	}
+
+	switch near := strings.TrimSpace(q.Get("near")); near {
+	case "":
+	case "me":
+		nearMe = true
+	default:
+		return nil, 0, false, fmt.Errorf("paramètre 'near' invalide: %q", near)
+	}
+	if nearMe && hasLat {
+		return nil, 0, false, fmt.Errorf("'near=me' et 'lat'/'lng' sont incompatibles")
+	}
+
+	radiusKm = config.DefaultRadiusKm
+	if raw := strings.TrimSpace(q.Get("radiusKm")); raw != "" {
+		radiusKm, err = strconv.ParseFloat(raw, 64)
+		if err != nil || !(radiusKm > 0 && radiusKm <= config.MaxRadiusKm) {
+			return nil, 0, false, fmt.Errorf("paramètre 'radiusKm' invalide (0 à %d)", config.MaxRadiusKm)
+		}
+	}
+
+	if hasLat {
+		center = &models.Coordinates{Latitude: lat, Longitude: lng}
+	}
+	return center, radiusKm, nearMe, nil
+}
+
+// withinRadius indique si des coordonnées sont dans le rayon du filtre ; sans centre, tout passe.
+// Un concert sans coordonnées n'est jamais retenu par un filtre géographique.
+func withinRadius(coords *models.Coordinates, criteria models.FilterCriteria) bool {
+	if criteria.Center == nil {
+		return true
+	}
+	return coords != nil && geo.DistanceKm(*criteria.Center, *coords) <= criteria.RadiusKm
+}
+
+// NearbyConcerts liste les concerts des artistes qui respectent lieu, pays, période et rayon,
+// du plus proche au plus lointain (puis par date)
+func NearbyConcerts(artists []models.Artist, criteria models.FilterCriteria) []models.NearbyConcert {
+	out := []models.NearbyConcert{}
+	if criteria.Center == nil {
+		return out
+	}
+	now := criteria.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for _, art := range artists {
+		for _, e := range art.Events {
+			if !matchesEvent(e, criteria, now) {
+				continue
+			}
+			out = append(out, models.NearbyConcert{
+				ArtistEvent: models.ArtistEvent{ArtistID: art.ID, ArtistName: art.Name, ConcertEvent: e},
+				DistanceKm:  math.Round(geo.DistanceKm(*criteria.Center, *e.Coordinates)*10) / 10,
+			})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].DistanceKm != out[j].DistanceKm {
+			return out[i].DistanceKm < out[j].DistanceKm
+		}
+		return out[i].Date.Before(out[j].Date)
+	})
+	return out
+}
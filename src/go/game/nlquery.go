@@ -0,0 +1,585 @@
+package game
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Types d'expressions reconnues par ParseQuery
+const (
+	PhraseCreation = "creation"
+	PhraseAlbum    = "album"
+	PhraseMembers  = "members"
+	PhraseCountry  = "country"
+	PhraseLocation = "location"
+	PhrasePeriod   = "period"
+	PhraseUpcoming = "upcoming"
+)
+
+func wordSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
+
+// Mots de liaison absorbés devant une expression reconnue ("from the", "playing in", "qui jouent en"...)
+var queryLeadWords = wordSet(
+	"from", "the", "in", "with", "playing", "play", "plays", "who", "that", "of", "at", "on", "during", "having", "has", "have",
+	"avec", "de", "des", "du", "en", "a", "au", "aux", "dans", "les", "la", "le", "l", "d", "qui", "jouent", "jouant", "joue", "pendant", "ayant", "ont",
+)
+
+// Mots génériques retirés du reste dès qu'une expression est reconnue
+var queryFillerWords = wordSet(
+	"bands", "band", "groups", "group", "artists", "artist", "musicians", "concerts", "concert", "shows", "show", "and",
+	"groupes", "groupe", "artistes", "artiste", "musiciens", "et",
+)
+
+var creationWords = wordSet("formed", "founded", "created", "started", "forme", "formes", "formee", "fonde", "fondes", "fondee", "cree", "crees", "creee", "nes", "ne")
+var albumWords = wordSet("album", "albums")
+var memberWords = wordSet("members", "member", "musicians", "membres", "membre", "musiciens")
+
+var numberWords = map[string]int{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+	"un": 1, "une": 1, "deux": 2, "trois": 3, "quatre": 4, "cinq": 5, "sept": 7, "huit": 8, "neuf": 9, "dix": 10, "onze": 11, "douze": 12,
+}
+
+// Formations nommées : "solo", "duo", "trio"...
+var lineupWords = map[string]int{"solo": 1, "duo": 2, "trio": 3, "quartet": 4, "quatuor": 4, "quartette": 4, "quintet": 5, "quintette": 5}
+
+var decadeWords = map[string]int{"sixties": 1960, "seventies": 1970, "eighties": 1980, "nineties": 1990}
+
+// countryAliases associe des noms de pays (français ou variantes anglaises) au nom du jeu de données
+var countryAliases = map[string]string{
+	"allemagne": "germany", "angleterre": "uk", "england": "uk", "royaume uni": "uk", "united kingdom": "uk", "great britain": "uk",
+	"etats unis": "usa", "united states": "usa", "america": "usa", "amerique": "usa",
+	"japon": "japan", "espagne": "spain", "bresil": "brazil", "mexique": "mexico", "pays bas": "netherlands", "holland": "netherlands",
+	"hollande": "netherlands", "nouvelle zelande": "new zealand", "polynesie francaise": "french polynesia",
+	"nouvelle caledonie": "new caledonia", "suisse": "switzerland", "italie": "italy", "belgique": "belgium", "suede": "sweden",
+	"norvege": "norway", "danemark": "denmark", "autriche": "austria", "pologne": "poland", "irlande": "ireland", "ecosse": "scotland",
+	"chine": "china", "coree du sud": "south korea", "inde": "india", "australie": "australia", "argentine": "argentina",
+	"chili": "chile", "colombie": "colombia", "perou": "peru", "finlande": "finland", "hongrie": "hungary", "grece": "greece",
+	"turquie": "turkey", "roumanie": "romania", "indonesie": "indonesia", "thailande": "thailand", "emirats arabes unis": "united arab emirates",
+	"arabie saoudite": "saudi arabia", "republique tcheque": "czech republic", "slovaquie": "slovakia", "bielorussie": "belarus",
+}
+
+type queryToken struct {
+	text       string
+	folded     string
+	start, end int
+}
+
+type queryParser struct {
+	text      string
+	tokens    []queryToken
+	used      []bool
+	countries map[string]string   // nom normalisé -> pays du jeu de données
+	cities    map[string][]string // ville normalisée -> lieux affichables
+	maxWords  int
+	now       time.Time
+	phrases   []models.QueryPhrase
+}
+
+// ParseQuery transforme une requête en français ou en anglais, ex: "bands from the 90s with
+// 4 members playing in Germany next year", en critères de filtre et texte restant.
+// L'analyse est déterministe : des règles fixes, les pays et les villes de meta, et now pour les périodes relatives.
+func ParseQuery(text string, meta models.FilterMeta, now time.Time) models.ParsedQuery {
+	p := &queryParser{
+		text:      text,
+		tokens:    tokenizeQuery(text),
+		countries: map[string]string{},
+		cities:    map[string][]string{},
+		maxWords:  1,
+		now:       now,
+	}
+	p.used = make([]bool, len(p.tokens))
+	for _, c := range meta.Countries {
+		p.addName(p.countries, utils.FoldText(c), c)
+	}
+	for alias, canonical := range countryAliases {
+		for _, c := range meta.Countries {
+			if utils.FoldText(c) == canonical {
+				p.addName(p.countries, alias, c)
+			}
+		}
+	}
+	for _, loc := range meta.Locations {
+		city := strings.Split(loc, ", ")[0]
+		folded := utils.FoldText(city)
+		p.cities[folded] = append(p.cities[folded], loc)
+		p.maxWords = max(p.maxWords, len(strings.Fields(folded)))
+	}
+
+	rules := []func(i int, ctx queryContext) (int, *models.QueryPhrase){p.matchTime, p.matchBetween, p.matchDecade, p.matchYear, p.matchMembers, p.matchPlace}
+	for i := 0; i < len(p.tokens); i++ {
+		ctx := p.context(i)
+		for _, rule := range rules {
+			if n, phrase := rule(i, ctx); n > 0 {
+				// Une expression qui contredit les précédentes reste dans le texte non compris
+				if !p.conflicts(*phrase) {
+					p.add(ctx.start, i+n, *phrase)
+				}
+				i += n - 1
+				break
+			}
+		}
+	}
+	return p.result()
+}
+
+func (p *queryParser) addName(names map[string]string, folded, value string) {
+	names[folded] = value
+	p.maxWords = max(p.maxWords, len(strings.Fields(folded)))
+}
+
+// tokenizeQuery découpe la requête en mots (lettres et chiffres) en gardant leur position
+func tokenizeQuery(text string) []queryToken {
+	var out []queryToken
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		if folded := utils.FoldText(text[start:end]); folded != "" {
+			out = append(out, queryToken{text: text[start:end], folded: folded, start: start, end: end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+	return out
+}
+
+// word retourne le mot normalisé d'indice i, ou "" s'il est hors limites ou déjà utilisé
+func (p *queryParser) word(i int) string {
+	if i < 0 || i >= len(p.tokens) || p.used[i] {
+		return ""
+	}
+	return p.tokens[i].folded
+}
+
+// seq indique si les mots à partir de i sont exactement words
+func (p *queryParser) seq(i int, words ...string) bool {
+	for k, w := range words {
+		if p.word(i+k) != w {
+			return false
+		}
+	}
+	return true
+}
+
+// qualifier borne une valeur : "at least 3", "plus de 5", "before 1980"...
+type qualifier struct {
+	words  []string
+	min    bool // borne basse (sinon haute)
+	strict bool
+}
+
+var qualifiers = []qualifier{
+	{[]string{"at", "least"}, true, false}, {[]string{"au", "moins"}, true, false}, {[]string{"since"}, true, false},
+	{[]string{"depuis"}, true, false}, {[]string{"more", "than"}, true, true}, {[]string{"plus", "de"}, true, true},
+	{[]string{"over"}, true, true}, {[]string{"after"}, true, true}, {[]string{"apres"}, true, true},
+	{[]string{"at", "most"}, false, false}, {[]string{"au", "plus"}, false, false}, {[]string{"up", "to"}, false, false},
+	{[]string{"until"}, false, false}, {[]string{"jusqu"}, false, false}, {[]string{"less", "than"}, false, true},
+	{[]string{"fewer", "than"}, false, true}, {[]string{"moins", "de"}, false, true}, {[]string{"under"}, false, true},
+	{[]string{"before"}, false, true}, {[]string{"avant"}, false, true},
+}
+
+// queryContext décrit les mots qui précèdent une expression et précisent son sens
+type queryContext struct {
+	start     int        // premier mot absorbé par l'expression
+	qualifier *qualifier // "at least", "avant"...
+	subject   string     // PhraseAlbum ("premier album") ou PhraseCreation ("formed", "fondé")
+	leads     []string   // mots de liaison absorbés ("from", "in", "en"...)
+}
+
+// context remonte depuis le mot i sur les mots de liaison, un qualificatif et un sujet
+func (p *queryParser) context(i int) queryContext {
+	ctx := queryContext{start: i}
+	for {
+		k := ctx.start - 1
+		if ctx.qualifier == nil && ctx.subject == "" {
+			if q := p.qualifierEndingAt(k); q != nil {
+				ctx.qualifier = q
+				ctx.start -= len(q.words)
+				continue
+			}
+		}
+		w := p.word(k)
+		switch {
+		case w == "":
+			return ctx
+		case queryLeadWords[w]:
+			ctx.leads = append(ctx.leads, w)
+			ctx.start--
+		case ctx.subject == "" && albumWords[w]:
+			ctx.subject = PhraseAlbum
+			ctx.start--
+			if w := p.word(k - 1); w == "first" || w == "premier" || w == "debut" {
+				ctx.start--
+			}
+		case ctx.subject == "" && creationWords[w]:
+			ctx.subject = PhraseCreation
+			ctx.start--
+		default:
+			return ctx
+		}
+	}
+}
+
+func (p *queryParser) qualifierEndingAt(k int) *qualifier {
+	for i := range qualifiers {
+		q := &qualifiers[i]
+		if p.seq(k-len(q.words)+1, q.words...) {
+			return q
+		}
+	}
+	return nil
+}
+
+// bounds applique le qualificatif à l'intervalle [lo, hi] (0 = non borné)
+func (ctx queryContext) bounds(lo, hi int) (int, int) {
+	switch q := ctx.qualifier; {
+	case q == nil:
+		return lo, hi
+	case q.min && q.strict:
+		return hi + 1, 0
+	case q.min:
+		return lo, 0
+	case q.strict:
+		return 0, lo - 1
+	default:
+		return 0, hi
+	}
+}
+
+func (ctx queryContext) hasLead(words ...string) bool {
+	for _, l := range ctx.leads {
+		for _, w := range words {
+			if l == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchTime reconnaît "next year", "l'année prochaine", "this year", "next month", "upcoming", "à venir"...
+func (p *queryParser) matchTime(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	year := p.now.Year()
+	thisMonth := time.Date(year, p.now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	switch {
+	case p.seq(i, "next", "year"), p.seq(i, "annee", "prochaine"), p.seq(i, "prochaine", "annee"):
+		return 2, periodPhrase(time.Date(year+1, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year+1, 12, 31, 0, 0, 0, 0, time.UTC), "Concerts l'année prochaine")
+	case p.seq(i, "this", "year"), p.seq(i, "cette", "annee"):
+		return 2, periodPhrase(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC), "Concerts cette année")
+	case p.seq(i, "next", "month"), p.seq(i, "mois", "prochain"):
+		return 2, periodPhrase(thisMonth.AddDate(0, 1, 0), thisMonth.AddDate(0, 2, -1), "Concerts le mois prochain")
+	case p.seq(i, "this", "month"), p.seq(i, "ce", "mois"):
+		return 2, periodPhrase(thisMonth, thisMonth.AddDate(0, 1, -1), "Concerts ce mois-ci")
+	case p.seq(i, "a", "venir"):
+		return 2, upcomingPhrase()
+	}
+	switch p.word(i) {
+	case "upcoming", "soon", "bientot", "prochainement":
+		return 1, upcomingPhrase()
+	}
+	return 0, nil
+}
+
+func periodPhrase(from, to time.Time, label string) *models.QueryPhrase {
+	return &models.QueryPhrase{Kind: PhrasePeriod, Label: label, Params: url.Values{
+		"dateFrom": {from.Format("2006-01-02")},
+		"dateTo":   {to.Format("2006-01-02")},
+	}}
+}
+
+func upcomingPhrase() *models.QueryPhrase {
+	return &models.QueryPhrase{Kind: PhraseUpcoming, Label: "Concerts à venir", Params: url.Values{"upcoming": {"1"}}}
+}
+
+// matchDecade reconnaît "90s", "1990s", "90's", "nineties", "années 90", "années 1990" :
+// décennie de création, ou du premier album après "album"
+func (p *queryParser) matchDecade(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	n, decade := 0, 0
+	if d, ok := decadeWords[p.word(i)]; ok {
+		n, decade = 1, d
+	} else if p.word(i) == "annees" {
+		if d, ok := parseDecade(p.word(i+1), true); ok {
+			n, decade = 2, d
+		}
+	} else if d, ok := parseDecade(p.word(i), p.word(i+1) == "s"); ok {
+		n, decade = 1, d
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	// "90's" : le "s" est un mot à part
+	if p.word(i+n) == "s" {
+		n++
+	}
+	lo, hi := ctx.bounds(decade, decade+9)
+	return matched(n, rangePhrase(ctx.subject, lo, hi))
+}
+
+// parseDecade lit "90s", "1990s", ou "90"/"1990" quand bare est vrai (après "années" ou avant "'s")
+func parseDecade(word string, bare bool) (int, bool) {
+	digits, found := strings.CutSuffix(word, "s")
+	if !found && !bare {
+		return 0, false
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil || n%10 != 0 {
+		return 0, false
+	}
+	switch {
+	case len(digits) == 2 && n >= 30:
+		return 1900 + n, true
+	case len(digits) == 2:
+		return 2000 + n, true
+	case len(digits) == 4 && n >= 1900 && n <= 2090:
+		return n, true
+	}
+	return 0, false
+}
+
+// matchYear reconnaît une année seule : création ("formed in 1975", "from 1975", "before 1980"),
+// premier album ("album in 1990") ou, par défaut, concerts de l'année ("in 2019", "en 2019")
+func (p *queryParser) matchYear(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	w := p.word(i)
+	year, ok := parseYear(w)
+	if !ok {
+		return 0, nil
+	}
+	if ctx.subject != "" || ctx.qualifier != nil || ctx.hasLead("from", "de", "des") {
+		lo, hi := ctx.bounds(year, year)
+		return matched(1, rangePhrase(ctx.subject, lo, hi))
+	}
+	return 1, periodPhrase(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC), "Concerts en "+w)
+}
+
+// matchBetween reconnaît "between 1980 and 1990", "entre 3 et 5 membres" : une seule expression,
+// bornes remises dans l'ordre si elles sont inversées
+func (p *queryParser) matchBetween(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	if (p.word(i) != "between" && p.word(i) != "entre") || ctx.qualifier != nil ||
+		(p.word(i+2) != "and" && p.word(i+2) != "et") {
+		return 0, nil
+	}
+	if lo, ok := parseYear(p.word(i + 1)); ok {
+		if hi, ok := parseYear(p.word(i + 3)); ok {
+			return 4, rangePhrase(ctx.subject, min(lo, hi), max(lo, hi))
+		}
+	}
+	lo, okLo := memberCount(p.word(i + 1))
+	hi, okHi := memberCount(p.word(i + 3))
+	if okLo && okHi && memberWords[p.word(i+4)] {
+		return 5, boundsPhrase(PhraseMembers, "membersMin", "membersMax", min(lo, hi), max(lo, hi))
+	}
+	return 0, nil
+}
+
+// parseYear lit une année de quatre chiffres entre 1900 et 2100
+func parseYear(word string) (int, bool) {
+	year, err := strconv.Atoi(word)
+	return year, err == nil && len(word) == 4 && year >= 1900 && year <= 2100
+}
+
+// memberCount lit un nombre de membres en chiffres (1 à 100) ou en lettres
+func memberCount(word string) (int, bool) {
+	if n, ok := numberWords[word]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(word)
+	return n, err == nil && n > 0 && n <= 100
+}
+
+// matchMembers reconnaît "4 members", "at least 3 members", "plus de 5 membres", "solo", "trio"...
+func (p *queryParser) matchMembers(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	if n, ok := lineupWords[p.word(i)]; ok {
+		return 1, boundsPhrase(PhraseMembers, "membersMin", "membersMax", n, n)
+	}
+	n, ok := memberCount(p.word(i))
+	if !ok {
+		return 0, nil
+	}
+	if !memberWords[p.word(i+1)] {
+		return 0, nil
+	}
+	lo, hi := ctx.bounds(n, n)
+	return matched(2, boundsPhrase(PhraseMembers, "membersMin", "membersMax", lo, hi))
+}
+
+// matched retourne n mots reconnus, ou aucun si l'expression a été rejetée
+func matched(n int, phrase *models.QueryPhrase) (int, *models.QueryPhrase) {
+	if phrase == nil {
+		return 0, nil
+	}
+	return n, phrase
+}
+
+// rangePhrase construit une expression de création, ou de premier album si subject le demande
+func rangePhrase(subject string, lo, hi int) *models.QueryPhrase {
+	if subject == PhraseAlbum {
+		return boundsPhrase(PhraseAlbum, "albumMin", "albumMax", lo, hi)
+	}
+	return boundsPhrase(PhraseCreation, "creationMin", "creationMax", lo, hi)
+}
+
+// boundsPhrase construit une expression bornée par [lo, hi] (0 = non borné) ; nil si aucune
+// borne ne reste, ex: "less than 1 member" qui ne laisse que des valeurs nulles
+func boundsPhrase(kind, minKey, maxKey string, lo, hi int) *models.QueryPhrase {
+	if lo <= 0 && hi <= 0 {
+		return nil
+	}
+	phrase := &models.QueryPhrase{Kind: kind, Params: url.Values{}}
+	if lo > 0 {
+		phrase.Params.Set(minKey, strconv.Itoa(lo))
+	}
+	if hi > 0 {
+		phrase.Params.Set(maxKey, strconv.Itoa(hi))
+	}
+
+	prefix := map[string]string{PhraseCreation: "Création", PhraseAlbum: "Premier album", PhraseMembers: "Membres"}[kind]
+	switch {
+	case lo > 0 && lo == hi:
+		phrase.Label = fmt.Sprintf("%s : %d", prefix, lo)
+	case lo > 0 && hi > 0:
+		phrase.Label = fmt.Sprintf("%s : %d–%d", prefix, lo, hi)
+	case lo > 0:
+		phrase.Label = fmt.Sprintf("%s : %d et plus", prefix, lo)
+	default:
+		phrase.Label = fmt.Sprintf("%s : jusqu'à %d", prefix, hi)
+	}
+	return phrase
+}
+
+// matchPlace reconnaît un pays (nom du jeu de données ou alias) ou, après un mot de liaison
+// ("in Paris", "à Lyon"), une ville ; la plus longue correspondance l'emporte
+func (p *queryParser) matchPlace(i int, ctx queryContext) (int, *models.QueryPhrase) {
+	for n := p.maxWords; n >= 1; n-- {
+		words := make([]string, 0, n)
+		for k := 0; k < n && p.word(i+k) != ""; k++ {
+			words = append(words, p.word(i+k))
+		}
+		if len(words) < n {
+			continue
+		}
+		name := strings.Join(words, " ")
+		if country, ok := p.countries[name]; ok {
+			return n, &models.QueryPhrase{Kind: PhraseCountry, Label: "Pays : " + country, Params: url.Values{"country": {country}}}
+		}
+		if locations, ok := p.cities[name]; ok && len(ctx.leads) > 0 {
+			return n, &models.QueryPhrase{Kind: PhraseLocation, Label: "Lieu : " + strings.Join(locations, " / "), Params: url.Values{"location": locations}}
+		}
+	}
+	return 0, nil
+}
+
+// rangeParams associe chaque borne basse des filtres à sa borne haute
+var rangeParams = map[string]string{"creationMin": "creationMax", "albumMin": "albumMax", "membersMin": "membersMax", "dateFrom": "dateTo"}
+
+// conflicts indique si phrase redéfinit une borne déjà posée ("90s 80s") ou laisse, avec
+// les expressions précédentes, un intervalle vide ("after 2000 before 1990")
+func (p *queryParser) conflicts(phrase models.QueryPhrase) bool {
+	bounds := map[string]string{}
+	for _, prev := range p.phrases {
+		for k, vs := range prev.Params {
+			if _, ok := phrase.Params[k]; ok && isRangeParam(k) {
+				return true
+			}
+			bounds[k] = vs[0]
+		}
+	}
+	for k, vs := range phrase.Params {
+		bounds[k] = vs[0]
+	}
+	for minKey, maxKey := range rangeParams {
+		lo, okLo := bounds[minKey]
+		hi, okHi := bounds[maxKey]
+		if !okLo || !okHi {
+			continue
+		}
+		// Années et membres se comparent en entiers, les dates AAAA-MM-JJ en chaînes
+		loN, errLo := strconv.Atoi(lo)
+		hiN, errHi := strconv.Atoi(hi)
+		if errLo == nil && errHi == nil {
+			if loN > hiN {
+				return true
+			}
+		} else if lo > hi {
+			return true
+		}
+	}
+	return false
+}
+
+// isRangeParam indique si key est une borne de rangeParams
+func isRangeParam(key string) bool {
+	for minKey, maxKey := range rangeParams {
+		if key == minKey || key == maxKey {
+			return true
+		}
+	}
+	return false
+}
+
+// add enregistre une expression avec les mots de contexte qui la précèdent
+func (p *queryParser) add(start, end int, phrase models.QueryPhrase) {
+	for k := start; k < end; k++ {
+		p.used[k] = true
+	}
+	phrase.Text = p.text[p.tokens[start].start:p.tokens[end-1].end]
+	p.phrases = append(p.phrases, phrase)
+}
+
+// result assemble les expressions, la query string de /api/filter et le reste du texte
+func (p *queryParser) result() models.ParsedQuery {
+	out := models.ParsedQuery{Query: p.text, Phrases: []models.QueryPhrase{}}
+	if len(p.phrases) == 0 {
+		// Sans expression reconnue, le texte est rendu tel quel
+		out.Remainder = strings.TrimSpace(p.text)
+		out.Criteria = models.FilterCriteria{Now: p.now}
+		return out
+	}
+
+	filter := url.Values{}
+	for _, phrase := range p.phrases {
+		out.Phrases = append(out.Phrases, phrase)
+		for k, vs := range phrase.Params {
+			switch k {
+			case "country", "location":
+				filter[k] = append(filter[k], vs...)
+			default:
+				filter[k] = vs
+			}
+		}
+	}
+
+	var rest []string
+	for k, tok := range p.tokens {
+		if !p.used[k] && !queryFillerWords[tok.folded] && !queryLeadWords[tok.folded] {
+			rest = append(rest, tok.text)
+		}
+	}
+	out.Remainder = strings.Join(rest, " ")
+	out.Filter = filter.Encode()
+	out.Criteria = FilterCriteriaFromValues(filter)
+	out.Criteria.Now = p.now
+	return out
+}
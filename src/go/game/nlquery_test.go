@@ -0,0 +1,79 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+func TestParseQueryMembers(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query     string
+		filter    string // query string attendue, "" si rien n'est reconnu
+		remainder string
+	}{
+		{"at least 3 members", "membersMin=3", ""},
+		{"bands with 4 members", "membersMax=4&membersMin=4", ""},
+		{"less than 2 members", "membersMax=1", ""},
+		{"moins de 2 membres", "membersMax=1", ""},
+		{"more than 5 members", "membersMin=6", ""},
+		// "less than 1" ne laisse aucune valeur : l'expression n'est pas comprise
+		{"less than 1 member", "", "less than 1 member"},
+		{"bands with fewer than 1 member", "", "bands with fewer than 1 member"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := ParseQuery(tt.query, models.FilterMeta{}, now)
+			if got.Filter != tt.filter {
+				t.Errorf("Filter = %q, attendu %q", got.Filter, tt.filter)
+			}
+			if got.Remainder != tt.remainder {
+				t.Errorf("Remainder = %q, attendu %q", got.Remainder, tt.remainder)
+			}
+			for _, phrase := range got.Phrases {
+				if strings.Contains(phrase.Label, "jusqu'à 0") {
+					t.Errorf("expression vide %q", phrase.Label)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQueryRanges(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		query     string
+		filter    string
+		remainder string
+		phrases   int
+	}{
+		{"between 2 and 3 members", "membersMax=3&membersMin=2", "", 1},
+		// Bornes inversées : remises dans l'ordre, l'expression reste entière
+		{"between 3 and 2 members", "membersMax=3&membersMin=2", "", 1},
+		{"entre trois et deux membres", "membersMax=3&membersMin=2", "", 1},
+		{"formed between 1990 and 1980", "creationMax=1990&creationMin=1980", "", 1},
+		{"first album between 2000 and 1995", "albumMax=2000&albumMin=1995", "", 1},
+		// Deux décennies : seule la première s'applique, la seconde reste non comprise
+		{"90s 80s", "creationMax=1999&creationMin=1990", "80s", 1},
+		{"formed after 2000 before 1990", "creationMin=2001", "before 1990", 1},
+		{"formed after 1980 before 1990", "creationMax=1989&creationMin=1981", "", 2},
+		{"at least 5 members less than 3 members", "membersMin=5", "less than 3 members", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := ParseQuery(tt.query, models.FilterMeta{}, now)
+			if got.Filter != tt.filter {
+				t.Errorf("Filter = %q, attendu %q", got.Filter, tt.filter)
+			}
+			if got.Remainder != tt.remainder {
+				t.Errorf("Remainder = %q, attendu %q", got.Remainder, tt.remainder)
+			}
+			if len(got.Phrases) != tt.phrases {
+				t.Errorf("%d expressions, attendu %d : %+v", len(got.Phrases), tt.phrases, got.Phrases)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"groupie/src/go/models"
+)
+
+// LoadOverlay lit le fichier d'overlay ({"<id>": ArtistMeta}). Un fichier absent n'est pas une erreur.
+func LoadOverlay(path string) (map[int]models.ArtistMeta, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("lecture overlay: %w", err)
+	}
+
+	var overlay map[int]models.ArtistMeta
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("décodage overlay: %w", err)
+	}
+	return overlay, nil
+}
+
+// ApplyMeta remplace les métadonnées locales de l'artiste par celles de l'overlay.
+// Les membres de l'overlay sont associés aux membres de l'API par leur nom.
+func ApplyMeta(art *models.Artist, meta *models.ArtistMeta) {
+	art.Genres = nil
+	art.Biography = ""
+	art.Links = nil
+	art.MemberDetails = nil
+	if meta == nil {
+		return
+	}
+
+	art.Genres = meta.Genres
+	art.Biography = meta.Biography
+	art.Links = meta.Links
+
+	if len(meta.Members) == 0 {
+		return
+	}
+	byName := make(map[string]models.MemberInfo, len(meta.Members))
+	for _, m := range meta.Members {
+		byName[strings.ToLower(strings.TrimSpace(m.Name))] = m
+	}
+	art.MemberDetails = make([]models.MemberInfo, len(art.Members))
+	for i, name := range art.Members {
+		info := byName[strings.ToLower(strings.TrimSpace(name))]
+		info.Name = name
+		art.MemberDetails[i] = info
+	}
+}
+
+// MemberRole retourne le rôle connu d'un membre, ou une chaîne vide
+func MemberRole(art models.Artist, member string) string {
+	for _, m := range art.MemberDetails {
+		if m.Name == member {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// ReloadOverlay relit le fichier d'overlay et l'applique aux artistes chargés, sans rappeler l'API
+func (d *DataService) ReloadOverlay() error {
+	// Lecture et application sous writeMu : un rafraîchissement concurrent ne peut pas
+	// publier une version plus ancienne du fichier
+	d.writeMu.Lock()
+	overlay, err := LoadOverlay(d.OverlayPath)
+	if err != nil {
+		d.writeMu.Unlock()
+		return err
+	}
+	d.setOverlay(overlay)
+	current := d.data.Load()
+	updated := make([]models.Artist, len(current.Artists))
+	copy(updated, current.Artists)
+	for i := range updated {
+		ApplyMeta(&updated[i], lookupMeta(overlay, updated[i].ID))
+	}
+	d.data.Store(NewDataset(updated, current.Locations, current.UpdatedAt))
+	d.writeMu.Unlock()
+
+	log.Printf("overlay: %d artistes enrichis", len(overlay))
+	return nil
+}
+
+// refreshOverlay relit l'overlay avant un rafraîchissement ; en cas d'erreur l'ancien est conservé
+func (d *DataService) refreshOverlay() map[int]models.ArtistMeta {
+	d.writeMu.Lock()
+	defer d.writeMu.Unlock()
+	overlay, err := LoadOverlay(d.OverlayPath)
+	if err != nil {
+		log.Printf("overlay: %v", err)
+		return d.getOverlay()
+	}
+	d.setOverlay(overlay)
+	return overlay
+}
+
+func (d *DataService) getOverlay() map[int]models.ArtistMeta {
+	d.overlayMu.RLock()
+	defer d.overlayMu.RUnlock()
+	return d.overlay
+}
+
+func (d *DataService) setOverlay(overlay map[int]models.ArtistMeta) {
+	d.overlayMu.Lock()
+	d.overlay = overlay
+	d.overlayMu.Unlock()
+}
+
+func lookupMeta(overlay map[int]models.ArtistMeta, id int) *models.ArtistMeta {
+	meta, ok := overlay[id]
+	if !ok {
+		return nil
+	}
+	return &meta
+}
@@ -0,0 +1,204 @@
+package game
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// Types d'incohérences relevées entre relations, lieux et dates
+const (
+	IssueAsteriskDates           = "asterisk_dates"
+	IssueLocationMissingRelation = "location_missing_in_relation"
+	IssueLocationMissingSource   = "location_missing_in_locations"
+	IssueDateMissingRelation     = "date_missing_in_relation"
+	IssueDateMissingSource       = "date_missing_in_dates"
+	IssueMisaligned              = "locations_dates_misaligned"
+)
+
+// crossSources regroupe les lieux et dates de chaque artiste, indexés par ID
+type crossSources struct {
+	locations map[int]models.APILocation
+	dates     map[int]models.APIDate
+}
+
+// fetchCrossSources charge les index de lieux et de dates. Une source indisponible
+// désactive seulement la vérification croisée.
+func (d *DataService) fetchCrossSources(ctx context.Context, index *models.APIIndex) crossSources {
+	sources := crossSources{}
+
+	if index.LocationsURL != "" {
+		locations, err := d.Source.FetchLocations(ctx, index.LocationsURL)
+		if err != nil {
+			log.Printf("vérification croisée: %v", err)
+		} else {
+			sources.locations = make(map[int]models.APILocation, len(locations))
+			for _, l := range locations {
+				sources.locations[l.ID] = l
+			}
+		}
+	}
+
+	if index.DatesURL != "" {
+		dates, err := d.Source.FetchDates(ctx, index.DatesURL)
+		if err != nil {
+			log.Printf("vérification croisée: %v", err)
+		} else {
+			sources.dates = make(map[int]models.APIDate, len(dates))
+			for _, dt := range dates {
+				sources.dates[dt.ID] = dt
+			}
+		}
+	}
+
+	return sources
+}
+
+// ReconcileRelation compare la relation d'un artiste avec ses listes de lieux et de dates.
+// L'API préfixe par "*" la première date de chaque lieu : quand le nombre de groupes
+// correspond au nombre de lieux, lieux et dates forment une seconde relation, et ce
+// que les deux sources confirment est réintégré dans la relation.
+func ReconcileRelation(artist models.APIArtist, rel models.APIRelation, locations *models.APILocation, dates *models.APIDate) (models.APIRelation, []models.DataIssue) {
+	var issues []models.DataIssue
+	issue := func(kind, location string, values []string, repaired bool) {
+		issues = append(issues, models.DataIssue{
+			ArtistID:   artist.ID,
+			ArtistName: artist.Name,
+			Kind:       kind,
+			Location:   location,
+			Values:     values,
+			Repaired:   repaired,
+		})
+	}
+
+	repaired := models.APIRelation{ID: rel.ID, DatesLocations: make(map[string][]string, len(rel.DatesLocations))}
+	for loc, ds := range rel.DatesLocations {
+		repaired.DatesLocations[loc] = ds
+	}
+
+	relDates := make(map[string]struct{})
+	for _, ds := range rel.DatesLocations {
+		for _, dt := range ds {
+			relDates[dt] = struct{}{}
+		}
+	}
+
+	var groups [][]string
+	if dates != nil {
+		var starred []string
+		for _, raw := range dates.Dates {
+			clean := strings.TrimPrefix(raw, "*")
+			if clean != raw {
+				starred = append(starred, clean)
+				groups = append(groups, nil)
+			} else if len(groups) == 0 {
+				groups = append(groups, nil)
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], clean)
+		}
+		if len(starred) > 0 {
+			issue(IssueAsteriskDates, "", starred, false)
+		}
+
+		var missing []string
+		for _, g := range groups {
+			for _, dt := range g {
+				if _, ok := relDates[dt]; !ok {
+					missing = append(missing, dt)
+				}
+			}
+		}
+		if len(missing) > 0 && (locations == nil || len(groups) != len(locations.Locations)) {
+			issue(IssueDateMissingRelation, "", missing, false)
+		}
+
+		sourceDates := make(map[string]struct{}, len(dates.Dates))
+		for _, g := range groups {
+			for _, dt := range g {
+				sourceDates[dt] = struct{}{}
+			}
+		}
+		for loc, ds := range rel.DatesLocations {
+			var absent []string
+			for _, dt := range ds {
+				if _, ok := sourceDates[dt]; !ok {
+					absent = append(absent, dt)
+				}
+			}
+			if len(absent) > 0 {
+				issue(IssueDateMissingSource, loc, absent, false)
+			}
+		}
+	}
+
+	if locations != nil {
+		known := make(map[string]struct{}, len(locations.Locations))
+		for _, loc := range locations.Locations {
+			known[loc] = struct{}{}
+		}
+		for loc := range rel.DatesLocations {
+			if _, ok := known[loc]; !ok {
+				issue(IssueLocationMissingSource, loc, nil, false)
+			}
+		}
+
+		aligned := dates != nil && len(groups) == len(locations.Locations)
+		if dates != nil && !aligned {
+			issue(IssueMisaligned, "", nil, false)
+		}
+
+		for i, loc := range locations.Locations {
+			current, inRelation := repaired.DatesLocations[loc]
+			if !aligned {
+				if !inRelation {
+					issue(IssueLocationMissingRelation, loc, nil, false)
+				}
+				continue
+			}
+
+			if !inRelation {
+				repaired.DatesLocations[loc] = groups[i]
+				issue(IssueLocationMissingRelation, loc, groups[i], true)
+				continue
+			}
+
+			added := missingDates(groups[i], current)
+			if len(added) > 0 {
+				merged := make([]string, 0, len(current)+len(added))
+				merged = append(merged, current...)
+				merged = append(merged, added...)
+				repaired.DatesLocations[loc] = merged
+				issue(IssueDateMissingRelation, loc, added, true)
+			}
+		}
+	}
+
+	return repaired, issues
+}
+
+func buildQualityReport(results []relationResult) models.QualityReport {
+	report := models.QualityReport{GeneratedAt: time.Now(), Issues: []models.DataIssue{}}
+	for _, res := range results {
+		if res.Status != refreshOK {
+			continue
+		}
+		report.Checked++
+		for _, is := range res.issues {
+			if is.Repaired {
+				report.Repaired++
+			}
+			report.Issues = append(report.Issues, is)
+		}
+	}
+	return report
+}
+
+// QualityReport retourne les incohérences relevées au dernier rafraîchissement
+func (d *DataService) QualityReport() models.QualityReport {
+	d.reportMu.RLock()
+	defer d.reportMu.RUnlock()
+	return d.quality
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"groupie/src/go/models"
+)
+
+func TestReconcileRelation(t *testing.T) {
+	artist := models.APIArtist{ID: 1, Name: "Queen"}
+	tests := []struct {
+		name      string
+		relation  map[string][]string
+		locations []string // nil : liste des lieux indisponible
+		dates     []string // nil : liste des dates indisponible
+		want      map[string][]string
+		issues    []string // "type lieu valeurs réparé", triés
+	}{
+		{
+			name:      "sources concordantes",
+			relation:  map[string][]string{"a": {"d1", "d2"}, "b": {"d3"}},
+			locations: []string{"a", "b"},
+			dates:     []string{"*d1", "d2", "*d3"},
+			want:      map[string][]string{"a": {"d1", "d2"}, "b": {"d3"}},
+			issues:    []string{"asterisk_dates  [d1 d3] false"},
+		},
+		{
+			name:      "sans astérisque",
+			relation:  map[string][]string{"a": {"d1", "d2"}},
+			locations: []string{"a"},
+			dates:     []string{"d1", "d2"},
+			want:      map[string][]string{"a": {"d1", "d2"}},
+		},
+		{
+			name:      "lieu absent de la relation : réintégré",
+			relation:  map[string][]string{"a": {"d1", "d2"}},
+			locations: []string{"a", "b"},
+			dates:     []string{"*d1", "d2", "*d3"},
+			want:      map[string][]string{"a": {"d1", "d2"}, "b": {"d3"}},
+			issues: []string{
+				"asterisk_dates  [d1 d3] false",
+				"location_missing_in_relation b [d3] true",
+			},
+		},
+		{
+			name:      "date absente de la relation : réintégrée",
+			relation:  map[string][]string{"a": {"d1"}, "b": {"d3"}},
+			locations: []string{"a", "b"},
+			dates:     []string{"*d1", "d2", "*d3"},
+			want:      map[string][]string{"a": {"d1", "d2"}, "b": {"d3"}},
+			issues: []string{
+				"asterisk_dates  [d1 d3] false",
+				"date_missing_in_relation a [d2] true",
+			},
+		},
+		{
+			name:      "groupes et lieux désalignés : rien n'est réparé",
+			relation:  map[string][]string{"a": {"d1"}},
+			locations: []string{"a", "b"},
+			dates:     []string{"*d1", "d2"},
+			want:      map[string][]string{"a": {"d1"}},
+			issues: []string{
+				"asterisk_dates  [d1] false",
+				"date_missing_in_relation  [d2] false",
+				"location_missing_in_relation b [] false",
+				"locations_dates_misaligned  [] false",
+			},
+		},
+		{
+			name:      "lieu et date inconnus des listes",
+			relation:  map[string][]string{"a": {"d1"}, "c": {"d9"}},
+			locations: []string{"a"},
+			dates:     []string{"*d1"},
+			want:      map[string][]string{"a": {"d1"}, "c": {"d9"}},
+			issues: []string{
+				"asterisk_dates  [d1] false",
+				"date_missing_in_dates c [d9] false",
+				"location_missing_in_locations c [] false",
+			},
+		},
+		{
+			name:     "listes indisponibles",
+			relation: map[string][]string{"a": {"d1"}},
+			want:     map[string][]string{"a": {"d1"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var locations *models.APILocation
+			if tt.locations != nil {
+				locations = &models.APILocation{ID: 1, Locations: tt.locations}
+			}
+			var dates *models.APIDate
+			if tt.dates != nil {
+				dates = &models.APIDate{ID: 1, Dates: tt.dates}
+			}
+			rel := models.APIRelation{ID: 1, DatesLocations: tt.relation}
+			before := fmt.Sprint(rel.DatesLocations)
+
+			got, issues := ReconcileRelation(artist, rel, locations, dates)
+			if !maps.EqualFunc(got.DatesLocations, tt.want, slices.Equal) {
+				t.Errorf("relation = %v, attendu %v", got.DatesLocations, tt.want)
+			}
+			if after := fmt.Sprint(rel.DatesLocations); after != before {
+				t.Errorf("relation d'origine modifiée: %s, avant %s", after, before)
+			}
+
+			var summary []string
+			for _, is := range issues {
+				if is.ArtistID != artist.ID {
+					t.Errorf("incohérence attribuée à l'artiste %d", is.ArtistID)
+				}
+				summary = append(summary, fmt.Sprintf("%s %s %v %v", is.Kind, is.Location, is.Values, is.Repaired))
+			}
+			slices.Sort(summary)
+			if !slices.Equal(summary, tt.issues) {
+				t.Errorf("incohérences = %q, attendu %q", summary, tt.issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package game
+
+// raceEnabled signale le détecteur de courses, qui ralentit fortement les mesures de temps
+const raceEnabled = true
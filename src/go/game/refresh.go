@@ -0,0 +1,113 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+const (
+	refreshOK     = "ok"
+	refreshKept   = "kept"
+	refreshFailed = "failed"
+)
+
+type relationResult struct {
+	models.ArtistRefreshResult
+	artist models.Artist
+	issues []models.DataIssue
+	// retryAfter est l'attente demandée par l'API quand elle a limité les appels
+	retryAfter time.Duration
+}
+
+// retryAfter retourne l'attente portée par une *api.RateLimitError, 0 sinon
+func retryAfter(err error) time.Duration {
+	var limited *api.RateLimitError
+	if errors.As(err, &limited) {
+		return limited.RetryAfter
+	}
+	return 0
+}
+
+// fetchAllRelations charge les relations avec un nombre limité de workers.
+// Les résultats sont dans le même ordre que les artistes.
+func (d *DataService) fetchAllRelations(ctx context.Context, artists []models.APIArtist, sources crossSources, overlay map[int]models.ArtistMeta) []relationResult {
+	results := make([]relationResult, len(artists))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var done int64
+
+	d.setProgress(0, len(artists))
+
+	workers := config.RefreshWorkers
+	if workers > len(artists) {
+		workers = len(artists)
+	}
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = d.fetchArtistRelation(ctx, artists[idx], sources, lookupMeta(overlay, artists[idx].ID))
+				d.setProgress(int(atomic.AddInt64(&done, 1)), len(artists))
+			}
+		}()
+	}
+
+	for i := range artists {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+func (d *DataService) fetchArtistRelation(ctx context.Context, data models.APIArtist, sources crossSources, meta *models.ArtistMeta) relationResult {
+	start := time.Now()
+	res := relationResult{
+		ArtistRefreshResult: models.ArtistRefreshResult{ID: data.ID, Name: data.Name},
+	}
+
+	// Un seul niveau de retry : celui du client HTTP (backoff, Retry-After, disjoncteur)
+	var attempts atomic.Int32
+	rel, err := d.Source.FetchRelations(api.WithAttemptCount(ctx, &attempts), data.RelationsURL)
+	res.Attempts = max(int(attempts.Load()), 1)
+	if err == nil {
+		var locations *models.APILocation
+		if l, ok := sources.locations[data.ID]; ok {
+			locations = &l
+		}
+		var dates *models.APIDate
+		if dt, ok := sources.dates[data.ID]; ok {
+			dates = &dt
+		}
+		repaired, issues := ReconcileRelation(data, *rel, locations, dates)
+
+		res.Status = refreshOK
+		res.issues = issues
+		res.artist = CombineArtist(data, repaired, meta)
+		res.DurationMs = time.Since(start).Milliseconds()
+		return res
+	}
+
+	res.Status = refreshFailed
+	res.Error = err.Error()
+	res.retryAfter = retryAfter(err)
+	res.DurationMs = time.Since(start).Milliseconds()
+	return res
+}
+
+// LastReport retourne le rapport du dernier rafraîchissement
+func (d *DataService) LastReport() models.RefreshReport {
+	d.reportMu.RLock()
+	defer d.reportMu.RUnlock()
+	return d.lastReport
+}
@@ -0,0 +1,55 @@
+package game
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/models"
+)
+
+// TestFetchArtistRelationRetries vérifie que seul le client HTTP réessaie : MaxRetries+1
+// requêtes au plus par artiste, toutes comptées dans Attempts
+func TestFetchArtistRelationRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32 // réponses 503 avant le succès
+		status   string
+		calls    int32
+	}{
+		{"succès immédiat", 0, refreshOK, 1},
+		{"succès après un 503", 1, refreshOK, 2},
+		{"échec persistant", 100, refreshFailed, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.Write([]byte(`{"id":1,"datesLocations":{"paris-france":["01-01-2020"]}}`))
+			}))
+			defer srv.Close()
+
+			client := api.NewClient(srv.Client())
+			client.Retry = api.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+			client.BreakerThreshold = 100
+			d := &DataService{Source: client}
+
+			artist := models.APIArtist{ID: 1, Name: "Queen", RelationsURL: srv.URL}
+			res := d.fetchArtistRelation(context.Background(), artist, crossSources{}, nil)
+			if res.Status != tt.status {
+				t.Errorf("Status = %q, attendu %q (%s)", res.Status, tt.status, res.Error)
+			}
+			if n := calls.Load(); n != tt.calls || int32(res.Attempts) != tt.calls {
+				t.Errorf("%d requêtes, Attempts = %d ; attendu %d", n, res.Attempts, tt.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// Types de correspondances d'une recherche enregistrée
+const (
+	MatchArtist  = "artist"
+	MatchConcert = "concert"
+)
+
+// EncodeFilterCriteria sérialise des critères en paramètres de /api/filter ;
+// FilterCriteriaFromValues relit le résultat à l'identique
+func EncodeFilterCriteria(c models.FilterCriteria) url.Values {
+	q := url.Values{}
+	setInt := func(key string, v int) {
+		if v != 0 {
+			q.Set(key, strconv.Itoa(v))
+		}
+	}
+	setInt("creationMin", c.CreationMin)
+	setInt("creationMax", c.CreationMax)
+	setInt("albumMin", c.AlbumMin)
+	setInt("albumMax", c.AlbumMax)
+	setInt("membersMin", c.MembersMin)
+	setInt("membersMax", c.MembersMax)
+
+	for _, key := range sortedKeys(c.Locations) {
+		q.Add("location", key)
+	}
+	for _, key := range sortedKeys(c.Countries) {
+		q.Add("country", key)
+	}
+	if c.Member != "" {
+		q.Set("member", c.Member)
+	}
+	if !c.ConcertFrom.IsZero() {
+		q.Set("dateFrom", c.ConcertFrom.Format("2006-01-02"))
+	}
+	if !c.ConcertTo.IsZero() {
+		q.Set("dateTo", c.ConcertTo.Format("2006-01-02"))
+	}
+	if c.UpcomingOnly {
+		q.Set("upcoming", "1")
+	}
+	if c.FavoritesOnly {
+		q.Set("favorites", "1")
+	}
+	if c.NearMe {
+		q.Set("near", "me")
+	}
+	if c.Center != nil {
+		q.Set("lat", strconv.FormatFloat(c.Center.Latitude, 'f', -1, 64))
+		q.Set("lng", strconv.FormatFloat(c.Center.Longitude, 'f', -1, 64))
+	}
+	if c.Center != nil || c.NearMe {
+		q.Set("radiusKm", strconv.FormatFloat(c.RadiusKm, 'f', -1, 64))
+	}
+	return q
+}
+
+// DecodeFilterCriteria relit des critères sérialisés par EncodeFilterCriteria
+func DecodeFilterCriteria(raw string) (models.FilterCriteria, error) {
+	q, err := url.ParseQuery(raw)
+	if err != nil {
+		return models.FilterCriteria{}, fmt.Errorf("critères invalides: %w", err)
+	}
+	if _, _, _, err := ParseGeoParams(q); err != nil {
+		return models.FilterCriteria{}, err
+	}
+	return FilterCriteriaFromValues(q), nil
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SavedSearchMatches liste les artistes retenus par les critères et, pour chacun,
+// les concerts qui respectent les critères de concert (tous, s'il n'y en a pas)
+func SavedSearchMatches(artists []models.Artist, criteria models.FilterCriteria) []models.SearchMatch {
+	now := criteria.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var out []models.SearchMatch
+	for _, art := range artists {
+		if !MatchesCriteria(art, criteria) {
+			continue
+		}
+		out = append(out, models.SearchMatch{
+			Key:        fmt.Sprintf("%s:%d", MatchArtist, art.ID),
+			Kind:       MatchArtist,
+			ArtistID:   art.ID,
+			ArtistName: art.Name,
+		})
+		for _, e := range art.Events {
+			if !matchesEvent(e, criteria, now) {
+				continue
+			}
+			out = append(out, models.SearchMatch{
+				Key:        fmt.Sprintf("%s:%d:%s:%s", MatchConcert, art.ID, e.Location, e.Date.Format("2006-01-02")),
+				Kind:       MatchConcert,
+				ArtistID:   art.ID,
+				ArtistName: art.Name,
+				Location:   e.DisplayLocation,
+				Date:       e.Date,
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Types de résultats de recherche, dans l'ordre d'affichage par défaut
+const (
+	SearchArtist   = "artist"
+	SearchMember   = "member"
+	SearchLocation = "location"
+	SearchDate     = "date"
+	SearchCreation = "creation"
+)
+
+var searchGroupLabels = map[string]string{
+	SearchArtist:   "Artistes",
+	SearchMember:   "Membres",
+	SearchLocation: "Lieux",
+	SearchDate:     "Dates",
+	SearchCreation: "Création",
+}
+
+// searchEntry est un texte cherchable rattaché à un artiste
+type searchEntry struct {
+	Type       string
+	Value      string
+	Folded     string
+	Label      string
+	ArtistID   int
+	ArtistName string
+}
+
+// Search cherche la requête dans l'index du jeu de données courant.
+// La comparaison ignore accents et casse et tolère les fautes de frappe.
+func (d *DataService) Search(query string, limit int) models.SearchResponse {
+	return d.Current().Search(query, limit)
+}
+
+// artistEntries liste les textes cherchables d'un artiste
+func artistEntries(art *models.Artist) []searchEntry {
+	entries := make([]searchEntry, 0, 2+len(art.Members)+len(art.Concerts)*2)
+	add := func(kind, value, label string) {
+		entries = append(entries, searchEntry{
+			Type:       kind,
+			Value:      value,
+			Folded:     utils.FoldText(value),
+			Label:      label,
+			ArtistID:   art.ID,
+			ArtistName: art.Name,
+		})
+	}
+
+	add(SearchArtist, art.Name, art.Name+" — artiste")
+	for _, m := range art.Members {
+		add(SearchMember, m, m+" — membre de "+art.Name)
+	}
+	creation := strconv.Itoa(art.CreationDate)
+	add(SearchCreation, creation, art.Name+" — fondé en "+creation)
+	for _, c := range art.Concerts {
+		add(SearchLocation, c.DisplayLocation, c.DisplayLocation+" — concert de "+art.Name)
+		for _, d := range c.Dates {
+			date := strings.TrimPrefix(d, "*")
+			add(SearchDate, date, date+" — concert de "+art.Name+" à "+c.DisplayLocation)
+		}
+	}
+	return entries
+}
+
+func newSearchResult(e searchEntry, score float64) models.SearchResult {
+	return models.SearchResult{
+		Type:       e.Type,
+		Label:      e.Label,
+		Value:      e.Value,
+		ArtistID:   e.ArtistID,
+		ArtistName: e.ArtistName,
+		Score:      score,
+	}
+}
+
+// groupResults trie les résultats par score et les regroupe par type
+func groupResults(resp models.SearchResponse, results []models.SearchResult, limit int) models.SearchResponse {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Label < results[j].Label
+	})
+
+	byType := map[string]int{}
+	// Clé composée sans concaténation : aucune allocation par résultat
+	type resultKey struct{ kind, label string }
+	seen := make(map[resultKey]struct{}, len(results))
+	for _, r := range results {
+		key := resultKey{r.Type, r.Label}
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+
+		idx, ok := byType[r.Type]
+		if !ok {
+			idx = len(resp.Groups)
+			byType[r.Type] = idx
+			resp.Groups = append(resp.Groups, models.SearchGroup{Type: r.Type, Label: searchGroupLabels[r.Type]})
+		}
+		if limit > 0 && len(resp.Groups[idx].Results) >= limit {
+			continue
+		}
+		resp.Groups[idx].Results = append(resp.Groups[idx].Results, r)
+		resp.Total++
+	}
+	return resp
+}
+
+// MatchScore note la correspondance entre une requête et un texte déjà normalisés (0 = aucune).
+// Les textes exacts ne tolèrent pas de faute (dates, années) ; une requête de moins de
+// 3 caractères ne correspond qu'à des débuts de mots.
+func MatchScore(query, text string, exact bool) float64 {
+	switch {
+	case text == query:
+		return 1
+	case strings.HasPrefix(text, query):
+		return 0.9
+	case hasWordPrefix(text, query):
+		return 0.8
+	case len(query) >= 3 && strings.Contains(text, query):
+		return 0.7
+	}
+	if exact {
+		return 0
+	}
+
+	maxDist := typoTolerance(len(query))
+	if maxDist == 0 {
+		return 0
+	}
+
+	// Comparer la requête à chaque mot (ou groupe de mots de même longueur) du texte ;
+	// les mots sont séparés d'une seule espace, un groupe est donc une sous-chaîne
+	qWords := strings.Count(query, " ") + 1
+	best := maxDist + 1
+	for start := 0; start < len(text); {
+		end, n := start, 0
+		for n < qWords {
+			n++
+			k := strings.IndexByte(text[end:], ' ')
+			if k < 0 {
+				end = len(text)
+				break
+			}
+			if n == qWords {
+				end += k
+				break
+			}
+			end += k + 1
+		}
+		if n < qWords {
+			break
+		}
+		candidate := text[start:end]
+		if d := editDistance(query, candidate); d < best {
+			best = d
+		}
+		// Préfixe du mot, pour la saisie en cours
+		if len(candidate) > len(query) {
+			if d := editDistance(query, candidate[:len(query)]); d < best {
+				best = d
+			}
+		}
+		next := strings.IndexByte(text[start:], ' ')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	if best > maxDist {
+		return 0
+	}
+	return 0.6 - 0.1*float64(best)
+}
+
+// hasWordPrefix indique si un mot du texte commence par la requête
+func hasWordPrefix(text, query string) bool {
+	for i := 0; i < len(text); {
+		j := strings.Index(text[i:], query)
+		if j < 0 {
+			return false
+		}
+		if i+j == 0 || text[i+j-1] == ' ' {
+			return true
+		}
+		i += j + 1
+	}
+	return false
+}
+
+func typoTolerance(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance calcule la distance de Damerau-Levenshtein (transpositions adjacentes)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Les trois lignes tiennent sur la pile pour les mots courants
+	var buf [3 * 33]int
+	n := len(rb) + 1
+	rows := buf[:]
+	if 3*n > len(buf) {
+		rows = make([]int, 3*n)
+	}
+	prev2, prev, cur := rows[:n], rows[n:2*n], rows[2*n:3*n]
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,372 @@
+package game
+
+import (
+	"sort"
+	"strings"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// indexTerm regroupe les entrées d'un même type partageant un texte normalisé
+type indexTerm struct {
+	kind    string
+	folded  string
+	exact   bool
+	entries []int32 // triées par libellé
+}
+
+// trieNode est un nœud de l'arbre des préfixes du vocabulaire
+type trieNode struct {
+	labels   []byte
+	children []int32
+	word     int32 // -1 si aucun mot ne se termine ici
+}
+
+// SearchIndex est un index inversé construit à chaque rafraîchissement.
+// Les mots des textes indexés forment un vocabulaire rangé dans un arbre de
+// préfixes (préfixes et fautes de frappe) et indexé par trigrammes (sous-chaînes) ;
+// seuls les textes contenant un mot candidat sont notés par MatchScore.
+// Il n'est jamais modifié après construction.
+type SearchIndex struct {
+	entries   []searchEntry
+	terms     []indexTerm
+	words     []string
+	wordTerms [][]int32
+	nodes     []trieNode
+	grams     map[string][]int32
+}
+
+// BuildSearchIndex indexe noms, membres, années de création, lieux et dates. La construction
+// est coûteuse (environ 140 ms et 48 Mo alloués pour 10 000 artistes, voir BenchmarkBuildSearchIndex) :
+// elle a lieu une fois par jeu de données publié, une recherche reste ensuite sous la milliseconde.
+func BuildSearchIndex(artists []models.Artist) *SearchIndex {
+	idx := &SearchIndex{
+		grams: make(map[string][]int32),
+		nodes: []trieNode{{word: -1}},
+	}
+	termIDs := make(map[string]int32)
+	wordIDs := make(map[string]int32)
+
+	for i := range artists {
+		for _, e := range artistEntries(&artists[i]) {
+			entryID := int32(len(idx.entries))
+			idx.entries = append(idx.entries, e)
+
+			key := e.Type + "\x00" + e.Folded
+			termID, ok := termIDs[key]
+			if !ok {
+				termID = int32(len(idx.terms))
+				termIDs[key] = termID
+				idx.terms = append(idx.terms, indexTerm{kind: e.Type, folded: e.Folded, exact: isExactType(e.Type)})
+				for _, w := range strings.Fields(e.Folded) {
+					idx.addWord(wordIDs, w, termID)
+				}
+			}
+			idx.terms[termID].entries = append(idx.terms[termID].entries, entryID)
+		}
+	}
+
+	for i := range idx.terms {
+		entries := idx.terms[i].entries
+		if len(entries) > 1 {
+			sort.Slice(entries, func(a, b int) bool {
+				return idx.entries[entries[a]].Label < idx.entries[entries[b]].Label
+			})
+		}
+	}
+
+	return idx
+}
+
+func (idx *SearchIndex) addWord(wordIDs map[string]int32, word string, termID int32) {
+	wordID, ok := wordIDs[word]
+	if !ok {
+		wordID = int32(len(idx.words))
+		wordIDs[word] = wordID
+		idx.words = append(idx.words, word)
+		idx.wordTerms = append(idx.wordTerms, nil)
+		idx.insertWord(word, wordID)
+		for _, g := range trigrams(word) {
+			idx.grams[g] = append(idx.grams[g], wordID)
+		}
+	}
+	// Un mot répété dans un texte n'y renvoie qu'une fois
+	postings := idx.wordTerms[wordID]
+	if n := len(postings); n == 0 || postings[n-1] != termID {
+		idx.wordTerms[wordID] = append(postings, termID)
+	}
+}
+
+func (idx *SearchIndex) insertWord(word string, wordID int32) {
+	node := int32(0)
+	for i := 0; i < len(word); i++ {
+		next := idx.child(node, word[i])
+		if next < 0 {
+			next = int32(len(idx.nodes))
+			idx.nodes = append(idx.nodes, trieNode{word: -1})
+			idx.nodes[node].labels = append(idx.nodes[node].labels, word[i])
+			idx.nodes[node].children = append(idx.nodes[node].children, next)
+		}
+		node = next
+	}
+	idx.nodes[node].word = wordID
+}
+
+func (idx *SearchIndex) child(node int32, c byte) int32 {
+	n := &idx.nodes[node]
+	for i, l := range n.labels {
+		if l == c {
+			return n.children[i]
+		}
+	}
+	return -1
+}
+
+// trigrams retourne les trigrammes distincts d'un texte (sur les octets)
+func trigrams(text string) []string {
+	out := make([]string, 0, len(text))
+	for i := 0; i+3 <= len(text); i++ {
+		g := text[i : i+3]
+		dup := false
+		for _, seen := range out {
+			if seen == g {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
+func isExactType(kind string) bool {
+	return kind == SearchDate || kind == SearchCreation
+}
+
+// Search cherche la requête dans l'index ; voir MatchScore pour le classement
+func (idx *SearchIndex) Search(query string, limit int) models.SearchResponse {
+	resp := models.SearchResponse{Query: query, Groups: []models.SearchGroup{}}
+	q := utils.FoldText(query)
+	if q == "" || idx == nil {
+		return resp
+	}
+
+	// Candidats notés, regroupés par type et par palier de score (peu nombreux)
+	type tier struct {
+		kind  string
+		score float64
+		terms []int32
+	}
+	var tiers []tier
+	for _, termID := range idx.candidates(q) {
+		term := &idx.terms[termID]
+		score := MatchScore(q, term.folded, term.exact)
+		if score == 0 {
+			continue
+		}
+		i := 0
+		for i < len(tiers) && (tiers[i].kind != term.kind || tiers[i].score != score) {
+			i++
+		}
+		if i == len(tiers) {
+			tiers = append(tiers, tier{kind: term.kind, score: score})
+		}
+		tiers[i].terms = append(tiers[i].terms, termID)
+	}
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].score > tiers[j].score })
+
+	// Par type, ne matérialiser que les meilleurs paliers
+	var results []models.SearchResult
+	taken := map[string]int{}
+	for _, t := range tiers {
+		if limit > 0 && taken[t.kind] >= limit {
+			continue
+		}
+		for _, termID := range idx.firstTerms(t.terms, limit) {
+			entries := idx.terms[termID].entries
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+			for _, entryID := range entries {
+				results = append(results, newSearchResult(idx.entries[entryID], t.score))
+			}
+			taken[t.kind] += len(entries)
+		}
+	}
+
+	return groupResults(resp, results, limit)
+}
+
+// firstTerms garde les limit termes dont le premier libellé est le plus petit :
+// à score égal, ils contiennent les limit premiers résultats
+func (idx *SearchIndex) firstTerms(termIDs []int32, limit int) []int32 {
+	if limit <= 0 || len(termIDs) <= limit {
+		return termIDs
+	}
+	label := func(termID int32) string {
+		return idx.entries[idx.terms[termID].entries[0]].Label
+	}
+	best := make([]int32, 0, limit+1)
+	for _, termID := range termIDs {
+		if len(best) == limit && label(termID) >= label(best[limit-1]) {
+			continue
+		}
+		pos := sort.Search(len(best), func(i int) bool { return label(best[i]) > label(termID) })
+		best = append(best, 0)
+		copy(best[pos+1:], best[pos:])
+		best[pos] = termID
+		if len(best) > limit {
+			best = best[:limit]
+		}
+	}
+	return best
+}
+
+// candidates retourne les textes contenant un mot proche du mot le plus long de
+// la requête (préfixe, sous-chaîne ou faute de frappe tolérée) ; MatchScore
+// vérifie ensuite la requête entière.
+func (idx *SearchIndex) candidates(q string) []int32 {
+	qWords := strings.Fields(q)
+	anchor := qWords[len(qWords)-1]
+	for i, qw := range qWords[:len(qWords)-1] {
+		// Le premier mot peut n'être que la fin d'un mot du texte
+		if i == 0 && len(qw) < 3 {
+			continue
+		}
+		if len(qw) > len(anchor) {
+			anchor = qw
+		}
+	}
+	maxDist := min(typoTolerance(len(q)), typoTolerance(len(anchor)))
+
+	seen := make([]uint64, len(idx.terms)/64+1)
+	var out []int32
+	for _, wordID := range idx.matchWords(anchor, maxDist) {
+		for _, termID := range idx.wordTerms[wordID] {
+			if bit := uint64(1) << (termID % 64); seen[termID/64]&bit == 0 {
+				seen[termID/64] |= bit
+				out = append(out, termID)
+			}
+		}
+	}
+	return out
+}
+
+// matchWords retourne les mots du vocabulaire commençant par qw, le contenant
+// ou à au plus maxDist fautes de lui (mot entier ou préfixe)
+func (idx *SearchIndex) matchWords(qw string, maxDist int) []int32 {
+	var out []int32
+
+	node := int32(0)
+	for i := 0; i < len(qw) && node >= 0; i++ {
+		node = idx.child(node, qw[i])
+	}
+	if node >= 0 {
+		out = idx.collect(node, out)
+	}
+
+	if len(qw) >= 3 {
+		out = append(out, idx.containing(qw)...)
+	}
+	if maxDist > 0 {
+		out = idx.fuzzy(qw, maxDist, out)
+	}
+	return out
+}
+
+// collect ajoute tous les mots du sous-arbre
+func (idx *SearchIndex) collect(node int32, out []int32) []int32 {
+	n := &idx.nodes[node]
+	if n.word >= 0 {
+		out = append(out, n.word)
+	}
+	for _, c := range n.children {
+		out = idx.collect(c, out)
+	}
+	return out
+}
+
+// containing retourne les mots contenant qw ailleurs qu'à leur début
+func (idx *SearchIndex) containing(qw string) []int32 {
+	grams := trigrams(qw)
+	// Partir de la liste la plus courte et vérifier chaque mot
+	shortest := idx.grams[grams[0]]
+	for _, g := range grams[1:] {
+		if p := idx.grams[g]; len(p) < len(shortest) {
+			shortest = p
+		}
+	}
+	var out []int32
+	for _, wordID := range shortest {
+		w := idx.words[wordID]
+		if !strings.HasPrefix(w, qw) && strings.Contains(w, qw) {
+			out = append(out, wordID)
+		}
+	}
+	return out
+}
+
+// fuzzy parcourt l'arbre en calculant la distance de Damerau-Levenshtein ligne par ligne
+// et abandonne les branches trop éloignées de qw
+func (idx *SearchIndex) fuzzy(qw string, maxDist int, out []int32) []int32 {
+	n := len(qw)
+	// Une ligne par profondeur, réutilisée d'une branche à l'autre. Seule la bande
+	// |j - profondeur| <= maxDist peut rester sous le seuil : le reste vaut maxDist+1.
+	rows := make([][]int, n+maxDist+1)
+	for i := range rows {
+		rows[i] = make([]int, n+1)
+		for j := range rows[i] {
+			rows[i][j] = maxDist + 1
+		}
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	var walk func(node int32, depth int, last byte)
+	walk = func(node int32, depth int, last byte) {
+		nd := &idx.nodes[node]
+		prev, row := rows[depth], rows[depth+1]
+		var prev2 []int
+		if depth > 0 {
+			prev2 = rows[depth-1]
+		}
+		lo, hi := max(1, depth+1-maxDist), min(n, depth+1+maxDist)
+		for i, c := range nd.labels {
+			child := nd.children[i]
+			row[0] = depth + 1
+			best := row[0]
+			for j := lo; j <= hi; j++ {
+				cost := 1
+				if qw[j-1] == c {
+					cost = 0
+				}
+				row[j] = min(prev[j]+1, row[j-1]+1, prev[j-1]+cost)
+				if depth > 0 && j > 1 && qw[j-1] == last && qw[j-2] == c {
+					row[j] = min(row[j], prev2[j-2]+1)
+				}
+				best = min(best, row[j])
+			}
+			if best > maxDist {
+				continue
+			}
+
+			// Début de mot assez proche : tout le sous-arbre correspond
+			if depth+1 == n && row[n] <= maxDist {
+				out = idx.collect(child, out)
+				continue
+			}
+			if row[n] <= maxDist && idx.nodes[child].word >= 0 {
+				out = append(out, idx.nodes[child].word)
+			}
+			if depth+1 < n+maxDist {
+				walk(child, depth+1, c)
+			}
+		}
+	}
+	walk(0, 0, 0)
+	return out
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+func searchFixture() []models.Artist {
+	return []models.Artist{
+		{ID: 1, Name: "Queen", Members: []string{"Freddie Mercury", "Brian May"}, CreationDate: 1970,
+			Concerts: []models.Concert{{Location: "london-uk", DisplayLocation: "London, UK", Dates: []string{"01-01-2020"}}}},
+		{ID: 2, Name: "Pink Floyd", Members: []string{"Roger Waters", "David Gilmour"}, CreationDate: 1965},
+		{ID: 3, Name: "Júlio Pereira", Members: []string{"Júlio Pereira"}, CreationDate: 1980},
+		{ID: 4, Name: "Queens of the Stone Age", Members: []string{"Josh Homme"}, CreationDate: 1996},
+	}
+}
+
+// searchArtists retourne les artistes cités dans les résultats
+func searchArtists(resp models.SearchResponse) map[string]bool {
+	found := map[string]bool{}
+	for _, g := range resp.Groups {
+		for _, r := range g.Results {
+			found[r.ArtistName] = true
+		}
+	}
+	return found
+}
+
+func TestSearchIndex(t *testing.T) {
+	idx := BuildSearchIndex(searchFixture())
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+		not   []string
+	}{
+		{"préfixe", "pin", []string{"Pink Floyd"}, []string{"Queen"}},
+		{"préfixe de membre", "gilm", []string{"Pink Floyd"}, nil},
+		{"préfixe commun", "quee", []string{"Queen", "Queens of the Stone Age"}, nil},
+		{"faute de frappe", "qeen", []string{"Queen"}, []string{"Pink Floyd"}},
+		{"accents ignorés", "ju", []string{"Júlio Pereira"}, nil},
+		{"accent dans la requête", "jú", []string{"Júlio Pereira"}, nil},
+		{"lieu", "london", []string{"Queen"}, nil},
+		{"aucun résultat", "zzzz", nil, []string{"Queen", "Pink Floyd"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := searchArtists(idx.Search(tt.query, 10))
+			for _, name := range tt.want {
+				if !found[name] {
+					t.Errorf("Search(%q): %q absent (trouvés: %v)", tt.query, name, found)
+				}
+			}
+			for _, name := range tt.not {
+				if found[name] {
+					t.Errorf("Search(%q): %q inattendu", tt.query, name)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	tests := []struct {
+		query, text string
+		exact       bool
+		want        float64
+	}{
+		{"queen", "queen", false, 1},
+		{"que", "queen", false, 0.9},
+		{"floyd", "pink floyd", false, 0.8},
+		{"loyd", "pink floyd", false, 0.7},
+		{"qeen", "queen", false, 0.5},
+		{"quen", "queens of the stone age", false, 0.5},
+		{"pink flyod", "pink floyd", false, 0.5},
+		{"stone aeg", "queens of the stone age", false, 0.5},
+		{"pnik floyd", "the pink floyd", false, 0.5},
+		{"1975", "1976", true, 0},
+		{"zzzz", "queen", false, 0},
+		{"queen", "", false, 0},
+	}
+	for _, tt := range tests {
+		if got := MatchScore(tt.query, tt.text, tt.exact); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("MatchScore(%q, %q) = %v, attendu %v", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+// syntheticArtists génère n artistes aux noms, membres et lieux variés
+func syntheticArtists(n int) []models.Artist {
+	syllables := []string{"ka", "lo", "mi", "ra", "zu", "ne", "to", "vi", "sa", "dé", "bo", "ché"}
+	cities := []string{"paris-france", "london-uk", "berlin-germany", "tokyo-japan", "new_york-usa", "lyon-france"}
+	word := func(i int) string {
+		s := ""
+		for k := 0; k < 3; k++ {
+			s += syllables[i%len(syllables)]
+			i /= len(syllables)
+		}
+		return s
+	}
+	artists := make([]models.Artist, n)
+	for i := range artists {
+		city := cities[i%len(cities)]
+		artists[i] = models.Artist{
+			ID:           i + 1,
+			Name:         fmt.Sprintf("%s %s", word(i), word(i*7+3)),
+			Members:      []string{word(i*3+1) + " " + word(i*5+2), word(i*11 + 4)},
+			CreationDate: 1950 + i%70,
+			Concerts: []models.Concert{{
+				Location:        city,
+				DisplayLocation: city,
+				Dates:           []string{fmt.Sprintf("%02d-%02d-20%02d", i%28+1, i%12+1, i%25)},
+			}},
+		}
+	}
+	return artists
+}
+
+// searchQueries couvre préfixes courts, mot complet, faute de frappe, accent, lieu, année et absence
+var searchQueries = []string{"k", "ka", "kalomi", "kalmoi", "dé", "paris", "1975", "zzzz"}
+
+// TestSearchIndexBudget vérifie qu'une recherche sur 10 000 artistes reste sous la milliseconde
+// et que ses allocations ne croissent pas avec le nombre d'artistes
+func TestSearchIndexBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("index de 10 000 artistes")
+	}
+	small := BuildSearchIndex(syntheticArtists(1000))
+	large := BuildSearchIndex(syntheticArtists(10000))
+
+	for _, query := range searchQueries {
+		allocsSmall := testing.AllocsPerRun(5, func() { small.Search(query, 10) })
+		allocsLarge := testing.AllocsPerRun(5, func() { large.Search(query, 10) })
+		// Marge pour les résultats plus nombreux à classer, pas une allocation par artiste
+		if allocsLarge > allocsSmall*1.5+10 {
+			t.Errorf("Search(%q): %.0f allocations sur 10 000 artistes, %.0f sur 1 000", query, allocsLarge, allocsSmall)
+		}
+
+		if raceEnabled {
+			continue
+		}
+		// Meilleur de plusieurs essais : une pause du GC ne doit pas faire échouer le test
+		best := time.Hour
+		for range 5 {
+			start := time.Now()
+			large.Search(query, 10)
+			best = min(best, time.Since(start))
+		}
+		if best > time.Millisecond {
+			t.Errorf("Search(%q) sur 10 000 artistes: %v, budget 1 ms", query, best)
+		}
+	}
+}
+
+func BenchmarkSearchIndex(b *testing.B) {
+	artists := syntheticArtists(10000)
+	idx := BuildSearchIndex(artists)
+
+	for _, query := range searchQueries {
+		b.Run(query, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				idx.Search(query, 10)
+			}
+		})
+	}
+}
+
+func BenchmarkBuildSearchIndex(b *testing.B) {
+	artists := syntheticArtists(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		BuildSearchIndex(artists)
+	}
+}
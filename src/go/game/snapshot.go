@@ -0,0 +1,112 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/models"
+)
+
+// SaveSnapshot écrit le jeu de données courant sur disque (écriture atomique via un fichier temporaire).
+// Les écritures simultanées sont sérialisées.
+func (d *DataService) SaveSnapshot() error {
+	if d.SnapshotPath == "" {
+		return nil
+	}
+	d.snapshotMu.Lock()
+	defer d.snapshotMu.Unlock()
+
+	ds := d.Current()
+	data, err := json.Marshal(models.DataSnapshot{
+		Version:   config.SnapshotVersion,
+		SavedAt:   ds.UpdatedAt,
+		Artists:   ds.Artists,
+		Locations: ds.Locations,
+	})
+	if err != nil {
+		return fmt.Errorf("encodage snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(d.SnapshotPath), 0o755); err != nil {
+		return fmt.Errorf("dossier snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(d.SnapshotPath), filepath.Base(d.SnapshotPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("écriture snapshot: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.SnapshotPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("écriture snapshot: %w", err)
+	}
+	return nil
+}
+
+// scheduleSnapshot regroupe les sauvegardes rapprochées : une seule écriture, SnapshotDelay
+// après la première demande, avec le jeu de données publié à ce moment
+func (d *DataService) scheduleSnapshot() {
+	save := func() {
+		if err := d.SaveSnapshot(); err != nil {
+			log.Printf("snapshot: %v", err)
+		}
+	}
+	if d.SnapshotDelay <= 0 {
+		save()
+		return
+	}
+	if !d.savePending.CompareAndSwap(false, true) {
+		return
+	}
+	time.AfterFunc(d.SnapshotDelay, func() {
+		d.savePending.Store(false)
+		save()
+	})
+}
+
+// LoadSnapshot charge le snapshot sur disque dans l'application.
+// Un snapshot d'une autre version est ignoré.
+func (d *DataService) LoadSnapshot() error {
+	data, err := os.ReadFile(d.SnapshotPath)
+	if err != nil {
+		return fmt.Errorf("lecture snapshot: %w", err)
+	}
+
+	var snapshot models.DataSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("décodage snapshot: %w", err)
+	}
+	if snapshot.Version != config.SnapshotVersion {
+		return fmt.Errorf("snapshot version %d incompatible (attendu %d)", snapshot.Version, config.SnapshotVersion)
+	}
+	if len(snapshot.Artists) == 0 {
+		return fmt.Errorf("snapshot vide")
+	}
+
+	d.publish(NewDataset(snapshot.Artists, snapshot.Locations, snapshot.SavedAt))
+
+	return nil
+}
+
+// DataAge retourne l'ancienneté du jeu de données chargé
+func (d *DataService) DataAge() time.Duration {
+	updatedAt := d.Current().UpdatedAt
+	if updatedAt.IsZero() {
+		return 0
+	}
+	return time.Since(updatedAt)
+}
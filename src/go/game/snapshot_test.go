@@ -0,0 +1,85 @@
+package game
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"groupie/src/go/models"
+)
+
+// TestSaveSnapshotConcurrent écrit le snapshot depuis plusieurs goroutines pendant des mises
+// à jour de coordonnées : le fichier final doit rester lisible, sans fichier temporaire restant
+func TestSaveSnapshotConcurrent(t *testing.T) {
+	d := newTestService(t)
+	location := d.GetArtists()[0].Concerts[0].Location
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				d.SetCoordinates(map[string]models.Coordinates{location: {Latitude: float64(i), Longitude: float64(j)}})
+				if err := d.SaveSnapshot(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	reloaded := NewDataService(&models.App{}, nil, d.SnapshotPath, "")
+	if err := reloaded.LoadSnapshot(); err != nil {
+		t.Fatalf("snapshot illisible: %v", err)
+	}
+	tmps, _ := filepath.Glob(filepath.Join(filepath.Dir(d.SnapshotPath), "*.tmp"))
+	if len(tmps) > 0 {
+		t.Errorf("fichiers temporaires restants: %v", tmps)
+	}
+}
+
+// TestScheduleSnapshotCoalesces vérifie que des mises à jour rapprochées donnent une seule
+// écriture, qui contient la dernière
+func TestScheduleSnapshotCoalesces(t *testing.T) {
+	d := newTestService(t)
+	if err := os.Remove(d.SnapshotPath); err != nil {
+		t.Fatal(err)
+	}
+	d.SnapshotDelay = 50 * time.Millisecond
+	location := d.GetArtists()[0].Concerts[0].Location
+
+	for i := range 5 {
+		d.SetCoordinates(map[string]models.Coordinates{location: {Latitude: float64(i + 1), Longitude: 2}})
+	}
+	if _, err := os.Stat(d.SnapshotPath); !os.IsNotExist(err) {
+		t.Fatal("snapshot écrit avant le délai")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for d.savePending.Load() || !fileExists(d.SnapshotPath) {
+		if time.Now().After(deadline) {
+			t.Fatal("snapshot jamais écrit")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.snapshotMu.Lock()
+	defer d.snapshotMu.Unlock()
+
+	reloaded := NewDataService(&models.App{}, nil, d.SnapshotPath, "")
+	if err := reloaded.LoadSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range reloaded.GetArtists()[0].Concerts {
+		if c.Location == location && (c.Coordinates == nil || c.Coordinates.Latitude != 5) {
+			t.Errorf("coordonnées sauvegardées %v, attendu la dernière mise à jour", c.Coordinates)
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,80 @@
+package geo
+
+// CountryCodes associe les noms de pays des lieux de concert (normalisés avec placeKey)
+// à leur code ISO 3166-1 alpha-2, celui des fichiers GeoNames
+var CountryCodes = map[string]string{
+	"argentina":            "AR",
+	"australia":            "AU",
+	"austria":              "AT",
+	"belarus":              "BY",
+	"belgium":              "BE",
+	"brazil":               "BR",
+	"bulgaria":             "BG",
+	"canada":               "CA",
+	"chile":                "CL",
+	"china":                "CN",
+	"colombia":             "CO",
+	"costa rica":           "CR",
+	"croatia":              "HR",
+	"czech republic":       "CZ",
+	"czechia":              "CZ",
+	"denmark":              "DK",
+	"england":              "GB",
+	"estonia":              "EE",
+	"finland":              "FI",
+	"france":               "FR",
+	"french polynesia":     "PF",
+	"germany":              "DE",
+	"greece":               "GR",
+	"hong kong":            "HK",
+	"hungary":              "HU",
+	"iceland":              "IS",
+	"india":                "IN",
+	"indonesia":            "ID",
+	"ireland":              "IE",
+	"israel":               "IL",
+	"italy":                "IT",
+	"japan":                "JP",
+	"latvia":               "LV",
+	"lithuania":            "LT",
+	"luxembourg":           "LU",
+	"malaysia":             "MY",
+	"mexico":               "MX",
+	"morocco":              "MA",
+	"netherlands":          "NL",
+	"new caledonia":        "NC",
+	"new zealand":          "NZ",
+	"norway":               "NO",
+	"peru":                 "PE",
+	"philippines":          "PH",
+	"poland":               "PL",
+	"portugal":             "PT",
+	"qatar":                "QA",
+	"romania":              "RO",
+	"russia":               "RU",
+	"saudi arabia":         "SA",
+	"scotland":             "GB",
+	"serbia":               "RS",
+	"singapore":            "SG",
+	"slovakia":             "SK",
+	"slovenia":             "SI",
+	"south africa":         "ZA",
+	"south korea":          "KR",
+	"spain":                "ES",
+	"sweden":               "SE",
+	"switzerland":          "CH",
+	"taiwan":               "TW",
+	"thailand":             "TH",
+	"turkey":               "TR",
+	"uk":                   "GB",
+	"ukraine":              "UA",
+	"united arab emirates": "AE",
+	"united kingdom":       "GB",
+	"united states":        "US",
+	"uruguay":              "UY",
+	"us":                   "US",
+	"usa":                  "US",
+	"venezuela":            "VE",
+	"vietnam":              "VN",
+	"wales":                "GB",
+}
@@ -0,0 +1,21 @@
+package geo
+
+import (
+	"math"
+
+	"groupie/src/go/models"
+)
+
+// EarthRadiusKm est le rayon moyen de la Terre
+const EarthRadiusKm = 6371.0
+
+// DistanceKm calcule la distance orthodromique entre deux points (formule de haversine)
+func DistanceKm(a, b models.Coordinates) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLng := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
@@ -0,0 +1,203 @@
+package geo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"groupie/src/go/models"
+	"groupie/src/go/utils"
+)
+
+// Colonnes utiles d'un fichier de villes GeoNames (cities15000.txt, allCountries.txt...)
+const (
+	gazetteerName       = 1
+	gazetteerASCIIName  = 2
+	gazetteerAltNames   = 3
+	gazetteerLatitude   = 4
+	gazetteerLongitude  = 5
+	gazetteerCountry    = 8
+	gazetteerPopulation = 14
+)
+
+// placePunct retire la ponctuation des noms de lieux ("St. Louis" -> "st louis")
+var placePunct = strings.NewReplacer(".", " ", "'", "", "’", "")
+
+// placeKey normalise un nom de lieu pour le gazetier
+func placeKey(name string) string {
+	return strings.Join(strings.Fields(placePunct.Replace(utils.FoldText(name))), " ")
+}
+
+// Gazetteer est un index hors ligne de villes au format GeoNames : un lieu "ville, pays"
+// est résolu vers la ville la plus peuplée de ce nom dans ce pays. Replace permet
+// de l'importer à nouveau pendant que le serveur tourne.
+type Gazetteer struct {
+	mu     sync.RWMutex
+	cities []gazetteerCity
+	names  map[string][]int32 // nom normalisé -> villes, population décroissante
+}
+
+type gazetteerCity struct {
+	coords     models.Coordinates
+	country    string // code ISO 3166-1 alpha-2
+	population int64
+}
+
+// NewGazetteer retourne un gazetier vide
+func NewGazetteer() *Gazetteer {
+	return &Gazetteer{names: map[string][]int32{}}
+}
+
+// LoadGazetteer lit un fichier de villes GeoNames
+func LoadGazetteer(path string) (*Gazetteer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseGazetteer(file)
+}
+
+// ParseGazetteer lit des villes au format GeoNames : une ville par ligne, colonnes séparées
+// par des tabulations (nom, nom ASCII, noms alternatifs, latitude, longitude, code pays,
+// population...). Les lignes vides et celles commençant par # sont ignorées.
+func ParseGazetteer(r io.Reader) (*Gazetteer, error) {
+	g := NewGazetteer()
+	scanner := bufio.NewScanner(r)
+	// Les noms alternatifs de certaines villes dépassent la taille de ligne par défaut
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) <= gazetteerPopulation {
+			return nil, fmt.Errorf("ligne %d: %d colonnes, %d attendues au moins", line, len(fields), gazetteerPopulation+1)
+		}
+		lat, err := strconv.ParseFloat(fields[gazetteerLatitude], 64)
+		if err != nil || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("ligne %d: latitude invalide %q", line, fields[gazetteerLatitude])
+		}
+		lng, err := strconv.ParseFloat(fields[gazetteerLongitude], 64)
+		if err != nil || lng < -180 || lng > 180 {
+			return nil, fmt.Errorf("ligne %d: longitude invalide %q", line, fields[gazetteerLongitude])
+		}
+		var population int64
+		if raw := fields[gazetteerPopulation]; raw != "" {
+			if population, err = strconv.ParseInt(raw, 10, 64); err != nil {
+				return nil, fmt.Errorf("ligne %d: population invalide %q", line, raw)
+			}
+		}
+
+		id := int32(len(g.cities))
+		g.cities = append(g.cities, gazetteerCity{
+			coords:     models.Coordinates{Latitude: lat, Longitude: lng},
+			country:    strings.ToUpper(fields[gazetteerCountry]),
+			population: population,
+		})
+		names := []string{fields[gazetteerName], fields[gazetteerASCIIName]}
+		if alt := fields[gazetteerAltNames]; alt != "" {
+			names = append(names, strings.Split(alt, ",")...)
+		}
+		for _, name := range names {
+			key := placeKey(name)
+			if key == "" {
+				continue
+			}
+			// Une ville ne figure qu'une fois par nom, même si plusieurs variantes se confondent
+			if list := g.names[key]; len(list) == 0 || list[len(list)-1] != id {
+				g.names[key] = append(list, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ligne %d: %w", line+1, err)
+	}
+
+	for _, ids := range g.names {
+		sort.SliceStable(ids, func(i, j int) bool {
+			return g.cities[ids[i]].population > g.cities[ids[j]].population
+		})
+	}
+	return g, nil
+}
+
+// WriteGazetteer enregistre un fichier de villes importé, de façon atomique
+func WriteGazetteer(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Replace remplace le contenu du gazetier par celui de other
+func (g *Gazetteer) Replace(other *Gazetteer) {
+	other.mu.RLock()
+	cities, names := other.cities, other.names
+	other.mu.RUnlock()
+
+	g.mu.Lock()
+	g.cities, g.names = cities, names
+	g.mu.Unlock()
+}
+
+// Len retourne le nombre de villes
+func (g *Gazetteer) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.cities)
+}
+
+// Geocode résout "ville, pays", "ville pays" ou "ville" : le nom de ville le plus long
+// connu du gazetier est retenu, et le reste du lieu, s'il désigne un pays connu
+// (CountryCodes ou code à deux lettres), écarte les villes homonymes d'autres pays.
+func (g *Gazetteer) Geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	words := strings.Fields(placeKey(location))
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for n := len(words); n > 0; n-- {
+		ids := g.names[strings.Join(words[:n], " ")]
+		if len(ids) == 0 {
+			continue
+		}
+		country := countryCode(strings.Join(words[n:], " "))
+		for _, id := range ids {
+			if country == "" || g.cities[id].country == country {
+				coords := g.cities[id].coords
+				return &coords, nil
+			}
+		}
+	}
+	return nil, ErrNoCoordinates
+}
+
+func (g *Gazetteer) Source() string { return models.GeoSourceGazetteer }
+
+func (g *Gazetteer) Offline() bool { return true }
+
+// countryCode retourne le code ISO d'un nom de pays normalisé, "" s'il est inconnu
+func countryCode(name string) string {
+	if code, ok := CountryCodes[name]; ok {
+		return code
+	}
+	if len(name) == 2 {
+		return strings.ToUpper(name)
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func loadTestGazetteer(t *testing.T) *Gazetteer {
+	t.Helper()
+	g, err := LoadGazetteer("testdata/cities.tsv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGazetteerGeocode(t *testing.T) {
+	g := loadTestGazetteer(t)
+	if g.Len() != 7 {
+		t.Fatalf("Len() = %d, attendu 7", g.Len())
+	}
+
+	tests := []struct {
+		name     string
+		location string
+		lat, lng float64
+	}{
+		{"homonyme en France", "Paris, France", 48.85341, 2.3488},
+		{"homonyme aux États-Unis", "Paris, USA", 33.66094, -95.55551},
+		{"code pays", "paris us", 33.66094, -95.55551},
+		{"sans pays : la plus peuplée", "Paris", 48.85341, 2.3488},
+		{"même pays : la plus peuplée", "Springfield, USA", 37.21533, -93.29824},
+		{"nom alternatif", "Parigi", 48.85341, 2.3488},
+		{"accent dans le lieu", "São Paulo, Brazil", -23.5475, -46.63611},
+		{"accent absent du lieu", "sao paulo brazil", -23.5475, -46.63611},
+		{"accent dans le nom alternatif", "Munchen, Germany", 48.13743, 11.57549},
+		{"ponctuation", "St Louis, USA", 38.62727, -90.19789},
+		{"slug du jeu de données", "saint_louis-usa", 38.62727, -90.19789},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coords, err := g.Geocode(context.Background(), tt.location)
+			if err != nil {
+				t.Fatalf("Geocode(%q): %v", tt.location, err)
+			}
+			if coords.Latitude != tt.lat || coords.Longitude != tt.lng {
+				t.Errorf("Geocode(%q) = %v, %v ; attendu %v, %v", tt.location, coords.Latitude, coords.Longitude, tt.lat, tt.lng)
+			}
+		})
+	}
+
+	for _, location := range []string{"Paris, Germany", "Lyon, France", ""} {
+		if _, err := g.Geocode(context.Background(), location); !errors.Is(err, ErrNoCoordinates) {
+			t.Errorf("Geocode(%q) = %v, attendu ErrNoCoordinates", location, err)
+		}
+	}
+}
+
+func TestParseGazetteerErrors(t *testing.T) {
+	valid := "1\tParis\tParis\t\t48.85\t2.35\tP\tPPLC\tFR\t\t\t\t\t\t2138551\t\t\tEurope/Paris\t2024-01-01"
+	// replace remplace la colonne col de la ligne valide
+	replace := func(col int, value string) string {
+		fields := strings.Split(valid, "\t")
+		fields[col] = value
+		return strings.Join(fields, "\t")
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"colonnes manquantes", valid + "\n1\tParis\tParis", "ligne 2: 3 colonnes"},
+		{"latitude", "# commentaire\n\n" + replace(gazetteerLatitude, "91"), "ligne 3: latitude invalide \"91\""},
+		{"latitude non numérique", replace(gazetteerLatitude, "nord"), "ligne 1: latitude invalide"},
+		{"longitude", replace(gazetteerLongitude, "-181"), "ligne 1: longitude invalide"},
+		{"population", valid + "\n" + valid + "\n" + replace(gazetteerPopulation, "2,1M"), "ligne 3: population invalide \"2,1M\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGazetteer(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erreur = %v, attendu %q", err, tt.want)
+			}
+		})
+	}
+
+	g, err := ParseGazetteer(strings.NewReader(replace(gazetteerPopulation, "")))
+	if err != nil || g.Len() != 1 {
+		t.Errorf("population vide : %v, %d villes", err, g.Len())
+	}
+}
+
+func TestGazetteerReplace(t *testing.T) {
+	g := NewGazetteer()
+	if _, err := g.Geocode(context.Background(), "Paris, France"); !errors.Is(err, ErrNoCoordinates) {
+		t.Fatalf("gazetier vide : %v", err)
+	}
+	g.Replace(loadTestGazetteer(t))
+	if _, err := g.Geocode(context.Background(), "Paris, France"); err != nil {
+		t.Errorf("après Replace : %v", err)
+	}
+}
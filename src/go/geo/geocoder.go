@@ -0,0 +1,35 @@
+package geo
+
+import (
+	"context"
+	"errors"
+
+	"groupie/src/go/models"
+)
+
+// ErrNoCoordinates signale un lieu introuvable
+var ErrNoCoordinates = errors.New("aucune coordonnée")
+
+// Geocoder résout un lieu en coordonnées ; ErrNoCoordinates si le lieu lui est inconnu.
+// api.GeocodeService interroge ses Geocoder dans l'ordre, après ses caches.
+type Geocoder interface {
+	Geocode(ctx context.Context, location string) (*models.Coordinates, error)
+	// Source identifie le backend dans le cache de géocodage (models.GeoSource*)
+	Source() string
+	// Offline indique un backend local : ni appel réseau, ni limite de débit
+	Offline() bool
+}
+
+// VenueTable est le Geocoder des lieux prédéfinis (VenueCoordinates)
+type VenueTable struct{}
+
+func (VenueTable) Geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	if coords, ok := GetVenueCoordinates(location); ok {
+		return coords, nil
+	}
+	return nil, ErrNoCoordinates
+}
+
+func (VenueTable) Source() string { return models.GeoSourceVenue }
+
+func (VenueTable) Offline() bool { return true }
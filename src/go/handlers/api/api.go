@@ -1,70 +1,545 @@
 package api
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"groupie/src/go/api"
+	"groupie/src/go/config"
+	"groupie/src/go/db"
 	"groupie/src/go/game"
+	"groupie/src/go/geo"
+	"groupie/src/go/models"
+	"groupie/src/go/session"
 	"groupie/src/go/utils"
 )
 
 type APIHandler struct {
 	DataService    *game.DataService
 	GeocodeService *api.GeocodeService
+	GeocodeQueue   *game.GeocodeQueue
+	Gazetteer      *geo.Gazetteer
+	APIClient      *api.Client
 }
 
-func NewAPIHandler(dataService *game.DataService, geocodeService *api.GeocodeService) *APIHandler {
+func NewAPIHandler(dataService *game.DataService, geocodeService *api.GeocodeService, geocodeQueue *game.GeocodeQueue, gazetteer *geo.Gazetteer, apiClient *api.Client) *APIHandler {
 	return &APIHandler{
 		DataService:    dataService,
 		GeocodeService: geocodeService,
+		GeocodeQueue:   geocodeQueue,
+		Gazetteer:      gazetteer,
+		APIClient:      apiClient,
 	}
 }
 
 func (h *APIHandler) HandleArtists(w http.ResponseWriter, r *http.Request) {
-	artists := h.DataService.GetArtists()
-	utils.RespondJSON(w, artists)
+	data := h.DataService.Current()
+	respondArtists(w, r, data, data.Artists)
 }
 
+// HandleFilter filtre les artistes. Avec lat/lng ou near=me (ville du profil), la réponse
+// contient aussi les concerts dans le rayon et leur distance ; avec facets=1, les comptes
+// par facette (voir models.FilterResult).
 func (h *APIHandler) HandleFilter(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		h.handleFilterExpr(w, r)
+		return
+	}
+	if _, _, _, err := game.ParseGeoParams(r.URL.Query()); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	criteria := game.ParseFilterCriteria(r)
-	artists := h.DataService.GetArtists()
-	filtered := game.FilterArtists(artists, criteria)
-	utils.RespondJSON(w, filtered)
+
+	var user *models.User
+	if criteria.FavoritesOnly || criteria.NearMe {
+		var err error
+		user, err = session.GetUserFromRequest(r)
+		if err != nil {
+			utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+			return
+		}
+	}
+	if criteria.FavoritesOnly {
+		favorites, err := db.FavoriteIDs(user.ID)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		criteria.Favorites = favorites
+	}
+	if criteria.NearMe {
+		if user.City == "" {
+			utils.RespondError(w, http.StatusBadRequest, "Aucune ville renseignée dans le profil")
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		coords, err := h.GeocodeService.LookupCoordinates(ctx, user.City)
+		cancel()
+		if err != nil {
+			respondGeocodeError(w, err, "impossible de géocoder la ville du profil")
+			return
+		}
+		criteria.Center = coords
+	}
+
+	data := h.DataService.Current()
+	filtered := data.Filter(criteria)
+	if criteria.FavoritesOnly {
+		for i := range filtered {
+			filtered[i].IsFavorite = true
+		}
+	}
+	withFacets := game.IsChecked(r.URL.Query().Get("facets"))
+	if criteria.Center == nil && !withFacets {
+		respondArtists(w, r, data, filtered)
+		return
+	}
+
+	payload, err := artistsPayload(w, r, data, filtered)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := models.FilterResult{Artists: payload}
+	if criteria.Center != nil {
+		result.Center = criteria.Center
+		result.RadiusKm = criteria.RadiusKm
+		result.Concerts = game.NearbyConcerts(filtered, criteria)
+	}
+	if withFacets {
+		meta := game.BuildFilterMeta(data.Artists, data.Locations)
+		facets := game.BuildFacets(data.Artists, meta, criteria)
+		result.Facets = &facets
+	}
+	utils.RespondJSON(w, result)
+}
+
+// handleFilterExpr filtre avec l'arbre JSON du corps de la requête (voir game.FilterExpr).
+// sort, limit, fields... restent lus dans la query string.
+func (h *APIHandler) handleFilterExpr(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.MaxFilterBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.RespondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("filtre trop volumineux (%d octets au plus)", config.MaxFilterBodyBytes))
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+		return
+	}
+	expr, err := game.ParseFilterExpr(body, time.Now())
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if expr.UsesFavorites() {
+		user, err := session.GetUserFromRequest(r)
+		if err != nil {
+			utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+			return
+		}
+		favorites, err := db.FavoriteIDs(user.ID)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		expr.SetFavorites(favorites)
+	}
+
+	data := h.DataService.Current()
+	respondArtists(w, r, data, game.FilterArtistsExpr(data.Artists, expr))
 }
 
+// respondArtists applique sort, order, offset/limit ou cursor et fields.
+// Sans aucun de ces paramètres, la liste complète est renvoyée telle quelle.
+// artists est data.Artists ou une partie de ses artistes.
+func respondArtists(w http.ResponseWriter, r *http.Request, data *game.Dataset, artists []models.Artist) {
+	payload, err := artistsPayload(w, r, data, artists)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	utils.RespondJSON(w, payload)
+}
+
+// artistsPayload retourne la liste ou la page d'artistes demandée et renseigne X-Total-Count
+func artistsPayload(w http.ResponseWriter, r *http.Request, data *game.Dataset, artists []models.Artist) (interface{}, error) {
+	opts, paged, err := game.ParseListOptions(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+	if !paged {
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(artists)))
+		return artists, nil
+	}
+
+	page, err := data.List(artists, opts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(artists)))
+	return page, nil
+}
+
+// HandleRefresh lance un rafraîchissement en arrière-plan (admin uniquement) et retourne le job.
+// Un rafraîchissement déjà en cours est rejoint au lieu d'en démarrer un nouveau.
 func (h *APIHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
-	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
-	defer cancel()
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	job := h.DataService.StartRefresh("manual")
+	w.Header().Set("Location", "/api/refresh/"+job.ID)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+// HandleRefreshJob retourne l'avancement et le résultat d'un job (/api/refresh/{id})
+func (h *APIHandler) HandleRefreshJob(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/refresh/")
+	job, ok := h.DataService.GetJob(id)
+	if !ok {
+		utils.RespondError(w, http.StatusNotFound, "job introuvable")
+		return
+	}
+	utils.RespondJSON(w, job)
+}
+
+// HandleSearch cherche ?q= dans les artistes, membres, lieux et dates (résultats groupés par type)
+func (h *APIHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := config.SearchLimitPerType
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			utils.RespondError(w, http.StatusBadRequest, "paramètre 'limit' invalide")
+			return
+		}
+		limit = n
+	}
+	utils.RespondJSON(w, h.DataService.Search(q.Get("q"), limit))
+}
+
+// HandleParseQuery analyse une requête en langage naturel (?q=, français ou anglais) :
+// expressions comprises, query string de /api/filter équivalente et texte restant
+func (h *APIHandler) HandleParseQuery(w http.ResponseWriter, r *http.Request) {
+	meta := game.BuildFilterMeta(h.DataService.GetArtists(), h.DataService.GetLocations())
+	utils.RespondJSON(w, game.ParseQuery(r.URL.Query().Get("q"), meta, time.Now()))
+}
+
+// HandleEvents liste les concerts dans l'ordre chronologique.
+// Paramètres: when=upcoming|past, artist=<id>, order=desc.
+func (h *APIHandler) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	when := q.Get("when")
+	if when != game.EventsAll && when != game.EventsUpcoming && when != game.EventsPast {
+		utils.RespondError(w, http.StatusBadRequest, "paramètre 'when' invalide")
+		return
+	}
+
+	artists := h.DataService.GetArtists()
+	if idStr := q.Get("artist"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "id invalide")
+			return
+		}
+		art, ok := h.DataService.FindArtistByID(id)
+		if !ok {
+			utils.RespondError(w, http.StatusNotFound, "artiste introuvable")
+			return
+		}
+		artists = []models.Artist{art}
+	}
+
+	events := game.ListEvents(artists, when, time.Now())
+	if q.Get("order") == "desc" {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	utils.RespondJSON(w, events)
+}
+
+// HandleNextEvents retourne le prochain concert de chaque artiste
+func (h *APIHandler) HandleNextEvents(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, game.NextEvents(h.DataService.GetArtists(), time.Now()))
+}
 
-	if err := h.DataService.RefreshData(ctx); err != nil {
+// HandleChanges retourne les différences de données depuis ?since= (RFC 3339 ou timestamp Unix)
+func (h *APIHandler) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	var since time.Time
+	if raw := strings.TrimSpace(r.URL.Query().Get("since")); raw != "" {
+		if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			since = time.Unix(secs, 0)
+		} else if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			since = t
+		} else {
+			utils.RespondError(w, http.StatusBadRequest, "paramètre 'since' invalide")
+			return
+		}
+	}
+	utils.RespondJSON(w, h.DataService.ChangesSince(since))
+}
+
+// HandleRefreshReport expose le rapport du dernier rafraîchissement (admin uniquement)
+func (h *APIHandler) HandleRefreshReport(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	utils.RespondJSON(w, h.DataService.LastReport())
+}
+
+// HandleDataQuality expose les incohérences entre relations, lieux et dates (admin uniquement)
+func (h *APIHandler) HandleDataQuality(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	utils.RespondJSON(w, h.DataService.QualityReport())
+}
+
+// HandleReloadOverlay relit le fichier d'overlay des artistes (admin uniquement)
+func (h *APIHandler) HandleReloadOverlay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if !requireAdmin(w, r) {
+		return
+	}
+	if err := h.DataService.ReloadOverlay(); err != nil {
 		utils.RespondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	utils.RespondJSON(w, map[string]string{"status": "ok"})
 }
 
+// HandleGeocodeCache gère le cache de géocodage (admin uniquement) :
+// GET liste (?q=, source=, failed=1, limit=), PUT corrige un lieu ({"location", "lat", "lng"}),
+// DELETE supprime un lieu (?location=) ou purge une sélection (source=, failed=1 ou all=1).
+func (h *APIHandler) HandleGeocodeCache(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	q := r.URL.Query()
+
+	switch r.Method {
+	case http.MethodGet:
+		filter, err := geocodeFilter(q)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		limit := config.DefaultPageSize
+		if raw := q.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n <= 0 || n > config.MaxPageSize {
+				utils.RespondError(w, http.StatusBadRequest, fmt.Sprintf("paramètre 'limit' invalide (1 à %d)", config.MaxPageSize))
+				return
+			}
+			limit = n
+		}
+		entries, err := db.ListGeocodes(filter, limit)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		utils.RespondJSON(w, entries)
+
+	case http.MethodPut:
+		var req struct {
+			Location string   `json:"location"`
+			Lat      *float64 `json:"lat"`
+			Lng      *float64 `json:"lng"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+			return
+		}
+		key := api.GeocodeKey(req.Location)
+		if key == "" {
+			utils.RespondError(w, http.StatusBadRequest, "champ 'location' manquant")
+			return
+		}
+		if req.Lat == nil || req.Lng == nil || math.Abs(*req.Lat) > 90 || math.Abs(*req.Lng) > 180 {
+			utils.RespondError(w, http.StatusBadRequest, "'lat' (-90 à 90) et 'lng' (-180 à 180) sont requis")
+			return
+		}
+		entry := models.GeocodeEntry{
+			Key:         key,
+			Query:       strings.TrimSpace(req.Location),
+			Coordinates: &models.Coordinates{Latitude: *req.Lat, Longitude: *req.Lng},
+			Source:      models.GeoSourceManual,
+			UpdatedAt:   time.Now(),
+		}
+		if err := db.SaveGeocode(entry); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		h.GeocodeService.Forget(key)
+		utils.RespondJSON(w, entry)
+
+	case http.MethodDelete:
+		if location := q.Get("location"); location != "" {
+			key := api.GeocodeKey(location)
+			err := db.DeleteGeocode(key)
+			if errors.Is(err, sql.ErrNoRows) {
+				utils.RespondError(w, http.StatusNotFound, "lieu absent du cache")
+				return
+			}
+			if err != nil {
+				utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+				return
+			}
+			h.GeocodeService.Forget(key)
+			utils.RespondJSON(w, map[string]int64{"deleted": 1})
+			return
+		}
+
+		filter, err := geocodeFilter(q)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if filter == (db.GeocodeFilter{}) && !game.IsChecked(q.Get("all")) {
+			utils.RespondError(w, http.StatusBadRequest, "indiquez 'location', 'source', 'failed=1' ou 'all=1'")
+			return
+		}
+		deleted, err := db.PurgeGeocodes(filter)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		h.GeocodeService.ForgetAll()
+		utils.RespondJSON(w, map[string]int64{"deleted": deleted})
+
+	default:
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+	}
+}
+
+// HandleGazetteer gère le gazetier hors ligne (admin uniquement) : GET donne son chemin
+// et son nombre de villes, PUT importe un fichier de villes GeoNames (corps de la requête),
+// l'enregistre puis remet en file les lieux encore sans coordonnées. Les coordonnées déjà
+// en cache l'emportent sur le nouveau gazetier : la réponse le rappelle.
+func (h *APIHandler) HandleGazetteer(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	path := config.GetGazetteerPath()
+
+	switch r.Method {
+	case http.MethodGet:
+		utils.RespondJSON(w, map[string]any{"path": path, "cities": h.Gazetteer.Len()})
+
+	case http.MethodPut:
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, config.MaxGazetteerBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.RespondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("gazetier trop volumineux (%d octets au plus)", config.MaxGazetteerBytes))
+				return
+			}
+			utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+			return
+		}
+		gazetteer, err := geo.ParseGazetteer(bytes.NewReader(body))
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "gazetier invalide: "+err.Error())
+			return
+		}
+		if gazetteer.Len() == 0 {
+			utils.RespondError(w, http.StatusBadRequest, "gazetier vide")
+			return
+		}
+		if err := geo.WriteGazetteer(path, body); err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur lors de l'enregistrement du gazetier")
+			return
+		}
+		h.Gazetteer.Replace(gazetteer)
+		// La file consulte les backends hors ligne avant les échecs en cache
+		h.GeocodeQueue.EnqueueMissing(h.DataService.GetArtists())
+		utils.RespondJSON(w, map[string]any{
+			"path":   path,
+			"cities": gazetteer.Len(),
+			"note":   "les lieux déjà résolus gardent leurs coordonnées en cache ; DELETE /api/admin/geocode?source=gazetteer les purge, recalculées au prochain rafraîchissement",
+		})
+
+	default:
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+	}
+}
+
+// geocodeFilter lit q, source et failed
+func geocodeFilter(q url.Values) (db.GeocodeFilter, error) {
+	filter := db.GeocodeFilter{
+		Search:     strings.TrimSpace(q.Get("q")),
+		Source:     q.Get("source"),
+		FailedOnly: game.IsChecked(q.Get("failed")),
+	}
+	switch filter.Source {
+	case "", models.GeoSourceVenue, models.GeoSourceGazetteer, models.GeoSourceNominatim, models.GeoSourceManual:
+		return filter, nil
+	}
+	return filter, fmt.Errorf("paramètre 'source' invalide: %q", filter.Source)
+}
+
+// HandleUpstreamStatus expose l'état des disjoncteurs par hôte distant (admin uniquement)
+func (h *APIHandler) HandleUpstreamStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	utils.RespondJSON(w, map[string]interface{}{
+		"breakers": h.APIClient.BreakerStates(),
+	})
+}
+
+// HandleGeocode retourne les coordonnées d'un lieu de concert connu (?location=).
+// Répond 429 avec Retry-After quand la limite de débit de Nominatim est atteinte.
 func (h *APIHandler) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 	location := r.URL.Query().Get("location")
 	if location == "" {
 		utils.RespondError(w, http.StatusBadRequest, "paramètre 'location' manquant")
 		return
 	}
+	if !h.DataService.Current().HasLocation(location) {
+		utils.RespondError(w, http.StatusNotFound, "lieu de concert inconnu")
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	coords, err := h.GeocodeService.LookupCoordinates(ctx, location)
-	if err != nil {
+	if err != nil && !errors.Is(err, api.ErrRateLimited) {
 		rawLocation := strings.ReplaceAll(location, "-", " ")
 		coords, err = h.GeocodeService.LookupCoordinates(ctx, rawLocation)
-		if err != nil {
-			utils.RespondError(w, http.StatusNotFound, fmt.Sprintf("impossible de géocoder: %v", err))
-			return
-		}
+	}
+	if err != nil {
+		respondGeocodeError(w, err, "impossible de géocoder")
+		return
 	}
 
 	utils.RespondJSON(w, map[string]interface{}{
@@ -72,3 +547,33 @@ func (h *APIHandler) HandleGeocode(w http.ResponseWriter, r *http.Request) {
 		"lng": coords.Longitude,
 	})
 }
+
+// HandleGeocodeProgress donne l'avancement du géocodage en arrière-plan
+func (h *APIHandler) HandleGeocodeProgress(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, h.GeocodeQueue.Progress())
+}
+
+// respondGeocodeError répond 429 avec Retry-After si Nominatim est saturé, 404 sinon
+func respondGeocodeError(w http.ResponseWriter, err error, message string) {
+	var limited *api.RateLimitError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		utils.RespondError(w, http.StatusTooManyRequests, "trop de requêtes de géocodage, réessayez plus tard")
+		return
+	}
+	utils.RespondError(w, http.StatusNotFound, fmt.Sprintf("%s: %v", message, err))
+}
+
+// requireAdmin répond 401/403 et retourne false si l'utilisateur n'est pas admin
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	user, err := session.GetUserFromRequest(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non authentifié")
+		return false
+	}
+	if !user.IsAdmin && !user.IsOwner {
+		utils.RespondError(w, http.StatusForbidden, "Accès refusé")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"groupie/src/go/game/gametest"
+	"groupie/src/go/models"
+)
+
+// serve appelle handle et retourne la réponse enregistrée
+func serve(handle http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handle(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+// decodeIDs lit une liste d'artistes, ou une page (champ items)
+func decodeIDs(t *testing.T, body []byte) []int {
+	t.Helper()
+	var page struct {
+		Items []models.Artist `json:"items"`
+	}
+	var list []models.Artist
+	if err := json.Unmarshal(body, &list); err != nil {
+		if err := json.Unmarshal(body, &page); err != nil {
+			t.Fatalf("réponse illisible: %s", body)
+		}
+		list = page.Items
+	}
+	ids := make([]int, len(list))
+	for i, art := range list {
+		ids[i] = art.ID
+	}
+	return ids
+}
+
+func TestHandleArtistsAndFilter(t *testing.T) {
+	h := NewAPIHandler(gametest.NewDataService(t, 0), nil, nil, nil, nil)
+
+	tests := []struct {
+		name   string
+		handle http.HandlerFunc
+		target string
+		status int
+		ids    []int  // artistes attendus, dans l'ordre
+		error  string // extrait du message d'erreur attendu
+	}{
+		{"liste complète", h.HandleArtists, "/api/artists", http.StatusOK, []int{6, 3, 1, 4, 2, 5}, ""},
+		{"page triée", h.HandleArtists, "/api/artists?sort=creationDate&limit=2", http.StatusOK, []int{3, 4}, ""},
+		{"page décroissante", h.HandleArtists, "/api/artists?sort=memberCount&order=desc&limit=3", http.StatusOK, []int{2, 1, 4}, ""},
+		{"tri inconnu", h.HandleArtists, "/api/artists?sort=age", http.StatusBadRequest, nil, "paramètre 'sort' invalide"},
+		{"limite invalide", h.HandleArtists, "/api/artists?limit=0", http.StatusBadRequest, nil, "paramètre 'limit' invalide"},
+		{"création", h.HandleFilter, "/api/filter?creationMin=1990", http.StatusOK, []int{2, 5}, ""},
+		{"lieu", h.HandleFilter, "/api/filter?location=paris-france", http.StatusOK, []int{3}, ""},
+		{"pays et page", h.HandleFilter, "/api/filter?country=usa&sort=name&limit=1", http.StatusOK, []int{1}, ""},
+		{"rayon invalide", h.HandleFilter, "/api/filter?lat=100&lng=2", http.StatusBadRequest, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(tt.handle, tt.target)
+			if w.Code != tt.status {
+				t.Fatalf("statut %d, attendu %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.status != http.StatusOK {
+				var body map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || !strings.Contains(body["error"], tt.error) {
+					t.Errorf("erreur %q, attendu %q", body["error"], tt.error)
+				}
+				return
+			}
+			if ids := decodeIDs(t, w.Body.Bytes()); !slices.Equal(ids, tt.ids) {
+				t.Errorf("artistes %v, attendu %v", ids, tt.ids)
+			}
+		})
+	}
+}
+
+func TestHandleArtistsCursor(t *testing.T) {
+	h := NewAPIHandler(gametest.NewDataService(t, 0), nil, nil, nil, nil)
+
+	var ids []int
+	target := "/api/artists?limit=4"
+	for target != "" {
+		w := serve(h.HandleArtists, target)
+		if w.Code != http.StatusOK {
+			t.Fatalf("statut %d: %s", w.Code, w.Body)
+		}
+		if total := w.Header().Get("X-Total-Count"); total != "6" {
+			t.Errorf("X-Total-Count = %q", total)
+		}
+		var page models.ArtistPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, decodeIDs(t, w.Body.Bytes())...)
+		target = ""
+		if page.NextCursor != "" {
+			target = "/api/artists?limit=4&cursor=" + page.NextCursor
+		}
+	}
+	if want := []int{6, 3, 1, 4, 2, 5}; !slices.Equal(ids, want) {
+		t.Errorf("pages %v, attendu %v", ids, want)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	h := NewAPIHandler(gametest.NewDataService(t, 0), nil, nil, nil, nil)
+
+	w := serve(h.HandleSearch, "/api/search?q=queen")
+	var resp models.SearchResponse
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &resp) != nil {
+		t.Fatalf("statut %d: %s", w.Code, w.Body)
+	}
+	found := false
+	for _, g := range resp.Groups {
+		for _, r := range g.Results {
+			found = found || r.ArtistName == "Queen"
+		}
+	}
+	if !found {
+		t.Errorf("Queen absent: %s", w.Body)
+	}
+
+	if w := serve(h.HandleSearch, "/api/search?q=queen&limit=-1"); w.Code != http.StatusBadRequest {
+		t.Errorf("limit négatif: statut %d", w.Code)
+	}
+}
+
+// TestHandlersAllocs vérifie qu'une page coûte autant d'allocations avec 10 000 artistes qu'avec 100
+func TestHandlersAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("jeu de 10 000 artistes")
+	}
+	small := NewAPIHandler(gametest.NewDataService(t, 100), nil, nil, nil, nil)
+	large := NewAPIHandler(gametest.NewDataService(t, 10000), nil, nil, nil, nil)
+	allocs := func(handle http.HandlerFunc, target string) float64 {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		return testing.AllocsPerRun(10, func() {
+			handle(httptest.NewRecorder(), req)
+		})
+	}
+	for _, target := range []string{"/api/artists?limit=5", "/api/filter?location=paris-france&limit=5"} {
+		handle := func(h *APIHandler) http.HandlerFunc {
+			if strings.HasPrefix(target, "/api/filter") {
+				return h.HandleFilter
+			}
+			return h.HandleArtists
+		}
+		a, b := allocs(handle(small), target), allocs(handle(large), target)
+		// Quelques allocations d'écart tolérées (tampons d'encodage, détecteur de courses),
+		// loin d'une par artiste
+		if b > a+10 {
+			t.Errorf("%s : %.0f allocations pour 10 000 artistes, %.0f pour 100", target, b, a)
+		}
+	}
+}
+
+func BenchmarkHandlers(b *testing.B) {
+	for _, n := range []int{100, 10000} {
+		h := NewAPIHandler(gametest.NewDataService(b, n), nil, nil, nil, nil)
+		benchmarks := []struct {
+			name   string
+			target string
+			handle http.HandlerFunc
+		}{
+			{"artists", "/api/artists?limit=20", h.HandleArtists},
+			{"filter", "/api/filter?location=" + "paris-france" + "&limit=20", h.HandleFilter},
+			{"search", "/api/search?q=queen", h.HandleSearch},
+		}
+		for _, bm := range benchmarks {
+			b.Run(fmt.Sprintf("%s/%d artistes", bm.name, n), func(b *testing.B) {
+				req := httptest.NewRequest(http.MethodGet, bm.target, nil)
+				b.ReportAllocs()
+				for b.Loop() {
+					w := httptest.NewRecorder()
+					bm.handle(w, req)
+					if w.Code != http.StatusOK {
+						b.Fatalf("statut %d: %s", w.Code, w.Body)
+					}
+				}
+			})
+		}
+	}
+}
@@ -1,17 +1,18 @@
 package artist
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"html/template"
 	"log"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"groupie/src/go/api"
 	"groupie/src/go/game"
+	"groupie/src/go/models"
 	"groupie/src/go/session"
 	"groupie/src/go/templates"
 	"groupie/src/go/utils"
@@ -20,13 +21,15 @@ import (
 type ArtistHandler struct {
 	DataService    *game.DataService
 	GeocodeService *api.GeocodeService
+	GeocodeQueue   *game.GeocodeQueue
 	Templates      map[string]*template.Template
 }
 
-func NewArtistHandler(dataService *game.DataService, geocodeService *api.GeocodeService, tmpls map[string]*template.Template) *ArtistHandler {
+func NewArtistHandler(dataService *game.DataService, geocodeService *api.GeocodeService, geocodeQueue *game.GeocodeQueue, tmpls map[string]*template.Template) *ArtistHandler {
 	return &ArtistHandler{
 		DataService:    dataService,
 		GeocodeService: geocodeService,
+		GeocodeQueue:   geocodeQueue,
 		Templates:      tmpls,
 	}
 }
@@ -50,23 +53,7 @@ func (h *ArtistHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 25*time.Second)
-	defer cancel()
-
-	for i := range art.Concerts {
-		loc := art.Concerts[i].DisplayLocation
-		coords, err := h.GeocodeService.LookupCoordinates(ctx, loc)
-		if err != nil {
-			rawFallback := strings.ReplaceAll(art.Concerts[i].Location, "-", " ")
-			coords, err = h.GeocodeService.LookupCoordinates(ctx, rawFallback)
-		}
-		if err != nil {
-			log.Printf("geocode %s: %v", loc, err)
-			continue
-		}
-		art.Concerts[i].Coordinates = coords
-	}
-
+	// Rendu avec les coordonnées déjà connues ; la page demande les autres à /api/artist/{id}/coordinates
 	jsonBytes, err := json.Marshal(art)
 	if err != nil {
 		log.Printf("json marshal error: %v", err)
@@ -85,3 +72,70 @@ func (h *ArtistHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	templates.RenderTemplate(w, h.Templates, "artist.html", data)
 }
 
+// HandleCoordinates retourne les coordonnées des concerts d'un artiste
+// (/api/artist/{id}/coordinates). Les lieux absents des caches sont confiés à la file de
+// géocodage et listés dans Pending, à redemander après Retry-After.
+func (h *ArtistHandler) HandleCoordinates(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+	idStr, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/artist/"), "/coordinates")
+	if !ok {
+		utils.RespondError(w, http.StatusNotFound, "ressource introuvable")
+		return
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "id invalide")
+		return
+	}
+
+	art, ok := h.DataService.FindArtistByID(id)
+	if !ok {
+		utils.RespondError(w, http.StatusNotFound, "artiste introuvable")
+		return
+	}
+
+	result := models.ArtistCoordinates{
+		ArtistID:    art.ID,
+		Coordinates: make(map[string]models.Coordinates, len(art.Concerts)),
+		Pending:     []string{},
+		Failed:      []string{},
+	}
+	var missing []models.Concert
+	for _, c := range art.Concerts {
+		if c.Coordinates != nil {
+			result.Coordinates[c.Location] = *c.Coordinates
+		} else {
+			missing = append(missing, c)
+		}
+	}
+
+	// Seuls les caches sont lus ici : les lieux à chercher sur le réseau partent dans la
+	// file de géocodage, qui les déduplique et respecte le débit de Nominatim
+	resolved := map[string]models.Coordinates{}
+	var queued []models.Concert
+	for _, c := range missing {
+		coords, err := game.CachedConcert(h.GeocodeService, c)
+		switch {
+		case err == nil:
+			resolved[c.Location] = *coords
+			result.Coordinates[c.Location] = *coords
+		case errors.Is(err, api.ErrNotCached):
+			queued = append(queued, c)
+			result.Pending = append(result.Pending, c.Location)
+		default:
+			result.Failed = append(result.Failed, c.Location)
+		}
+	}
+	// Publiés par la file avec son prochain lot : une seule mise à jour du jeu de données
+	// et du snapshot pour les pages et le géocodage en arrière-plan
+	h.GeocodeQueue.Submit(resolved)
+	h.GeocodeQueue.Enqueue(queued)
+	if len(result.Pending) > 0 {
+		wait := max(h.GeocodeService.RetryAfter(), h.GeocodeQueue.Interval)
+		w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+	}
+	utils.RespondJSON(w, result)
+}
@@ -0,0 +1,150 @@
+package artist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"groupie/src/go/api"
+	"groupie/src/go/game"
+	"groupie/src/go/game/gametest"
+	"groupie/src/go/models"
+)
+
+// testTemplates remplace artist.html par un gabarit minimal
+func testTemplates() map[string]*template.Template {
+	return map[string]*template.Template{
+		"artist.html": template.Must(template.New("artist.html").Parse(`<h1>{{.Artist.Name}}</h1><script>const artist = {{.ArtistJSON}};</script>`)),
+	}
+}
+
+func TestHandle(t *testing.T) {
+	h := NewArtistHandler(gametest.NewDataService(t, 0), nil, nil, testTemplates())
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		handle http.HandlerFunc
+		status int
+		body   string // extrait attendu du corps
+	}{
+		{"page", http.MethodGet, "/artist?id=3", h.Handle, http.StatusOK, `<h1>Pink Floyd</h1><script>const artist = {"id":3,`},
+		{"id manquant", http.MethodGet, "/artist", h.Handle, http.StatusBadRequest, "id d'artiste manquant"},
+		{"id invalide", http.MethodGet, "/artist?id=abc", h.Handle, http.StatusBadRequest, "id invalide"},
+		{"artiste inconnu", http.MethodGet, "/artist?id=99", h.Handle, http.StatusNotFound, "artiste introuvable"},
+		{"coordonnées : méthode", http.MethodPost, "/api/artist/3/coordinates", h.HandleCoordinates, http.StatusMethodNotAllowed, "Méthode non autorisée"},
+		{"coordonnées : chemin", http.MethodGet, "/api/artist/3/concerts", h.HandleCoordinates, http.StatusNotFound, "ressource introuvable"},
+		{"coordonnées : id invalide", http.MethodGet, "/api/artist/x/coordinates", h.HandleCoordinates, http.StatusBadRequest, "id invalide"},
+		{"coordonnées : artiste inconnu", http.MethodGet, "/api/artist/99/coordinates", h.HandleCoordinates, http.StatusNotFound, "artiste introuvable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handle(w, httptest.NewRequest(tt.method, tt.target, nil))
+			if w.Code != tt.status {
+				t.Fatalf("statut %d, attendu %d: %s", w.Code, tt.status, w.Body)
+			}
+			body := w.Body.String()
+			if tt.status != http.StatusOK {
+				var resp map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("erreur illisible: %s", body)
+				}
+				body = resp["error"]
+			}
+			if !strings.Contains(body, tt.body) {
+				t.Errorf("corps %q, attendu %q", body, tt.body)
+			}
+		})
+	}
+}
+
+// countingGeocoder est un backend en ligne qui compte ses appels
+type countingGeocoder struct {
+	calls atomic.Int32
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, location string) (*models.Coordinates, error) {
+	g.calls.Add(1)
+	return &models.Coordinates{Latitude: 1, Longitude: 2}, nil
+}
+
+func (g *countingGeocoder) Source() string { return models.GeoSourceNominatim }
+func (g *countingGeocoder) Offline() bool  { return false }
+
+// TestHandleCoordinatesQueues vérifie que l'API publique ne géocode rien elle-même : les
+// lieux absents des caches partent, une seule fois, dans la file de géocodage
+func TestHandleCoordinatesQueues(t *testing.T) {
+	data := gametest.NewDataService(t, 0)
+	online := &countingGeocoder{}
+	service := api.NewGeocodeService(nil, online)
+	queue := game.NewGeocodeQueue(data, service)
+	h := NewArtistHandler(data, service, queue, nil)
+
+	// Les concerts de Pink Floyd perdent leurs coordonnées
+	artists := data.GetArtists()
+	missing := map[string]bool{}
+	for i := range artists {
+		if artists[i].ID != 3 {
+			continue
+		}
+		artists[i].Concerts = slices.Clone(artists[i].Concerts)
+		for j := range artists[i].Concerts {
+			artists[i].Concerts[j].Coordinates = nil
+			missing[artists[i].Concerts[j].Location] = true
+		}
+	}
+	if len(missing) == 0 {
+		t.Fatal("Pink Floyd n'a aucun concert dans le jeu d'essai")
+	}
+	data.UpdateArtists(artists)
+
+	for range 3 {
+		w := httptest.NewRecorder()
+		h.HandleCoordinates(w, httptest.NewRequest(http.MethodGet, "/api/artist/3/coordinates", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("statut %d: %s", w.Code, w.Body)
+		}
+		var got models.ArtistCoordinates
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Pending) != len(missing) || w.Header().Get("Retry-After") == "" {
+			t.Errorf("Pending = %v, Retry-After = %q ; attendu %d lieux en attente", got.Pending, w.Header().Get("Retry-After"), len(missing))
+		}
+	}
+
+	if n := online.calls.Load(); n != 0 {
+		t.Errorf("%d appels au géocodeur en ligne, attendu aucun", n)
+	}
+	if p := queue.Progress(); p.Queued != len(missing) || p.Pending != len(missing) {
+		t.Errorf("file : %d mis en file, %d en attente ; attendu %d sans doublon", p.Queued, p.Pending, len(missing))
+	}
+}
+
+// BenchmarkHandle montre que le rendu d'une page ne dépend pas de la taille du jeu de données
+func BenchmarkHandle(b *testing.B) {
+	tmpls := testTemplates()
+	for _, n := range []int{100, 10000} {
+		b.Run(fmt.Sprintf("%d artistes", n), func(b *testing.B) {
+			h := NewArtistHandler(gametest.NewDataService(b, n), nil, nil, tmpls)
+			req := httptest.NewRequest(http.MethodGet, "/artist?id=42", nil)
+			b.ReportAllocs()
+			for b.Loop() {
+				w := httptest.NewRecorder()
+				h.Handle(w, req)
+				if w.Code != http.StatusOK {
+					b.Fatalf("statut %d", w.Code)
+				}
+			}
+		})
+	}
+}
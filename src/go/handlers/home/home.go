@@ -1,49 +1,66 @@
 package home
 
 import (
+	"context"
 	"encoding/json"
 	"html/template"
 	"net/http"
+	"slices"
+	"time"
 
+	"groupie/src/go/api"
 	"groupie/src/go/db"
 	"groupie/src/go/game"
 	"groupie/src/go/models"
 	"groupie/src/go/session"
 	"groupie/src/go/templates"
+	"groupie/src/go/utils"
 )
 
 type HomeHandler struct {
-	DataService *game.DataService
-	Templates   map[string]*template.Template
+	DataService    *game.DataService
+	GeocodeService *api.GeocodeService
+	Templates      map[string]*template.Template
 }
 
-func NewHomeHandler(dataService *game.DataService, tmpls map[string]*template.Template) *HomeHandler {
+func NewHomeHandler(dataService *game.DataService, geocodeService *api.GeocodeService, tmpls map[string]*template.Template) *HomeHandler {
 	return &HomeHandler{
-		DataService: dataService,
-		Templates:   tmpls,
+		DataService:    dataService,
+		GeocodeService: geocodeService,
+		Templates:      tmpls,
 	}
 }
 
 func (h *HomeHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	artists := h.DataService.GetArtists()
+	// Un seul jeu de données pour toute la page, même si un rafraîchissement a lieu entre-temps
+	data := h.DataService.Current()
 
 	user, err := session.GetUserFromRequest(r)
 	favSet := make(map[int]struct{}) // struct{} utilise 0 bytes vs bool qui utilise 1 byte
 	if err == nil {
-		rows, err := db.DB.Query("SELECT artist_id FROM favorites WHERE user_id = ?", user.ID)
-		if err == nil {
-			defer rows.Close()
-			for rows.Next() {
-				var fid int
-				if err := rows.Scan(&fid); err == nil {
-					favSet[fid] = struct{}{}
-				}
-			}
+		if favorites, err := db.FavoriteIDs(user.ID); err == nil {
+			favSet = favorites
 		}
 	}
 
-	displayArtists := make([]models.Artist, len(artists))
-	copy(displayArtists, artists)
+	// Les filtres passés dans l'URL (mêmes paramètres que /api/filter) s'appliquent au rendu initial
+	var displayArtists []models.Artist
+	if len(r.URL.Query()) > 0 {
+		criteria := game.ParseFilterCriteria(r)
+		criteria.Favorites = favSet
+		// « Autour de moi » : centré sur la ville du profil, ignoré si elle est inconnue
+		if criteria.NearMe && user != nil && user.City != "" {
+			ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+			if coords, err := h.GeocodeService.LookupCoordinates(ctx, user.City); err == nil {
+				criteria.Center = coords
+			}
+			cancel()
+		}
+		displayArtists = data.Filter(criteria)
+	} else {
+		// Copie : les favoris sont marqués en place et le jeu de données est partagé
+		displayArtists = slices.Clone(data.Artists)
+	}
 
 	// Marquer les favoris
 	for i := range displayArtists {
@@ -70,16 +87,17 @@ func (h *HomeHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	meta := game.BuildFilterMeta(displayArtists, h.DataService.App.Locations)
+	meta := game.BuildFilterMeta(data.Artists, data.Locations)
 
-	data := map[string]interface{}{
+	page := map[string]interface{}{
 		"ArtistsJSON": template.JS(string(jsonPayload)),
 		"FilterMeta":  meta,
+		"DataAge":     utils.FormatAge(h.DataService.DataAge()),
 	}
 
 	if user != nil {
-		data["User"] = user
+		page["User"] = user
 	}
 
-	templates.RenderTemplate(w, h.Templates, "home.html", data)
+	templates.RenderTemplate(w, h.Templates, "home.html", page)
 }
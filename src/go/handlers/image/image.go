@@ -0,0 +1,73 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"groupie/src/go/game"
+	"groupie/src/go/images"
+	"groupie/src/go/utils"
+)
+
+type ImageHandler struct {
+	DataService *game.DataService
+	Cache       *images.Cache
+}
+
+func NewImageHandler(dataService *game.DataService, cache *images.Cache) *ImageHandler {
+	return &ImageHandler{
+		DataService: dataService,
+		Cache:       cache,
+	}
+}
+
+// Handle sert /img/artist/{id}?size=thumb|card depuis le cache disque.
+// Si l'origine échoue, une image de remplacement est servie avec un cache court.
+func (h *ImageHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/img/artist/"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, "id invalide")
+		return
+	}
+
+	sizeName := r.URL.Query().Get("size")
+	if sizeName == "" {
+		sizeName = images.SizeCard.Name
+	}
+	size, ok := images.Sizes[sizeName]
+	if !ok {
+		utils.RespondError(w, http.StatusBadRequest, "taille inconnue")
+		return
+	}
+
+	art, ok := h.DataService.FindArtistByID(id)
+	if !ok {
+		utils.RespondError(w, http.StatusNotFound, "artiste introuvable")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
+	defer cancel()
+
+	variant, err := h.Cache.Get(ctx, art.ID, art.Image, size)
+	if err != nil {
+		log.Printf("image artiste %d: %v", art.ID, err)
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		serveVariant(w, r, h.Cache.PlaceholderVariant(size))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=604800")
+	serveVariant(w, r, variant)
+}
+
+func serveVariant(w http.ResponseWriter, r *http.Request, v images.Variant) {
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("ETag", v.ETag)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(v.Data))
+}
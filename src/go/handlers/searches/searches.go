@@ -0,0 +1,262 @@
+package searches
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"groupie/src/go/config"
+	"groupie/src/go/db"
+	"groupie/src/go/game"
+	"groupie/src/go/models"
+	"groupie/src/go/notify"
+	"groupie/src/go/session"
+	"groupie/src/go/utils"
+)
+
+type SearchesHandler struct {
+	DataService *game.DataService
+	Notifier    *notify.Notifier
+}
+
+func NewSearchesHandler(dataService *game.DataService, notifier *notify.Notifier) *SearchesHandler {
+	return &SearchesHandler{
+		DataService: dataService,
+		Notifier:    notifier,
+	}
+}
+
+// searchRequest est le corps de POST /api/searches et PUT /api/searches/{id} ;
+// filter est une query string de /api/filter (ex: "country=france&upcoming=1")
+type searchRequest struct {
+	Name   string  `json:"name"`
+	Filter *string `json:"filter"`
+}
+
+// HandleSearches liste (GET) ou crée (POST) les recherches enregistrées (/api/searches)
+func (h *SearchesHandler) HandleSearches(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromRequest(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		searches, err := db.SavedSearches(user.ID)
+		if err != nil {
+			log.Printf("recherches enregistrées: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		utils.RespondJSON(w, searches)
+
+	case http.MethodPost:
+		var req searchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+			return
+		}
+		name, err := validName(req.Name)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if req.Filter == nil {
+			utils.RespondError(w, http.StatusBadRequest, "champ 'filter' manquant")
+			return
+		}
+		criteria, err := normalizeFilter(*req.Filter)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		count, err := db.CountSavedSearches(user.ID)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		if count >= config.MaxSavedSearches {
+			utils.RespondError(w, http.StatusConflict, "Nombre maximal de recherches enregistrées atteint")
+			return
+		}
+
+		search, err := db.CreateSavedSearch(user.ID, name, criteria)
+		if err != nil {
+			log.Printf("création recherche: %v", err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		h.baseline(search)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusCreated)
+		utils.RespondJSON(w, search)
+
+	default:
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+	}
+}
+
+// HandleSearch lit (GET), modifie (PUT) ou supprime (DELETE) une recherche (/api/searches/{id})
+func (h *SearchesHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromRequest(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+		return
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/searches/"))
+	if err != nil || id <= 0 {
+		utils.RespondError(w, http.StatusBadRequest, "ID invalide")
+		return
+	}
+
+	current, err := db.GetSavedSearch(user.ID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		utils.RespondError(w, http.StatusNotFound, "Recherche introuvable")
+		return
+	}
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		utils.RespondJSON(w, current)
+
+	case http.MethodPut:
+		var req searchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+			return
+		}
+		name := current.Name
+		if req.Name != "" {
+			if name, err = validName(req.Name); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+		criteria := current.Criteria
+		if req.Filter != nil {
+			if criteria, err = normalizeFilter(*req.Filter); err != nil {
+				utils.RespondError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		if err := db.UpdateSavedSearch(user.ID, id, name, criteria); err != nil {
+			log.Printf("modification recherche %d: %v", id, err)
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		updated, err := db.GetSavedSearch(user.ID, id)
+		if err != nil {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		if criteria != current.Criteria {
+			h.baseline(updated)
+		}
+		utils.RespondJSON(w, updated)
+
+	case http.MethodDelete:
+		if err := db.DeleteSavedSearch(user.ID, id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+	}
+}
+
+// HandleNotifications liste les notifications de l'utilisateur (?unread=1 : non lues seulement)
+func (h *SearchesHandler) HandleNotifications(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+	user, err := session.GetUserFromRequest(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+		return
+	}
+
+	unread := game.IsChecked(r.URL.Query().Get("unread"))
+	notifications, err := db.Notifications(user.ID, unread, config.NotificationsLimit)
+	if err != nil {
+		log.Printf("notifications: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+		return
+	}
+	utils.RespondJSON(w, notifications)
+}
+
+// HandleMarkRead marque des notifications comme lues ({"ids": [...]}, toutes si vide)
+func (h *SearchesHandler) HandleMarkRead(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.RespondError(w, http.StatusMethodNotAllowed, "Méthode non autorisée")
+		return
+	}
+	user, err := session.GetUserFromRequest(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, "Non connecté")
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, "Requête invalide")
+			return
+		}
+	}
+	if err := db.MarkNotificationsRead(user.ID, req.IDs); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, "Erreur base de données")
+		return
+	}
+	utils.RespondJSON(w, map[string]bool{"success": true})
+}
+
+// baseline marque les correspondances actuelles comme vues : seules les suivantes notifient
+func (h *SearchesHandler) baseline(search models.SavedSearch) {
+	if _, err := h.Notifier.Evaluate(search, h.DataService.GetArtists(), false); err != nil {
+		log.Printf("recherche %d: %v", search.ID, err)
+	}
+}
+
+func validName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("nom manquant")
+	}
+	if utf8.RuneCountInString(name) > config.MaxSavedSearchName {
+		return "", errors.New("nom trop long")
+	}
+	return name, nil
+}
+
+// normalizeFilter valide une query string de /api/filter et la réécrit sous forme canonique
+func normalizeFilter(raw string) (string, error) {
+	q, err := url.ParseQuery(strings.TrimPrefix(strings.TrimSpace(raw), "?"))
+	if err != nil {
+		return "", errors.New("filtre invalide")
+	}
+	if _, _, nearMe, err := game.ParseGeoParams(q); err != nil {
+		return "", err
+	} else if nearMe {
+		return "", errors.New("near=me n'est pas pris en charge : indiquez lat et lng")
+	}
+	return game.EncodeFilterCriteria(game.FilterCriteriaFromValues(q)).Encode(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"html/template"
 	"net/http"
+	"strings"
 
 	"groupie/src/go/db"
 	"groupie/src/go/session"
@@ -90,6 +91,7 @@ func (h *UserHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		email := r.FormValue("email")
 		password := r.FormValue("password")
+		city := strings.TrimSpace(r.FormValue("city"))
 
 		if email != "" && email != user.Email {
 			_, err := db.DB.Exec("UPDATE users SET email = ? WHERE id = ?", email, user.ID)
@@ -101,6 +103,16 @@ func (h *UserHandler) HandleProfile(w http.ResponseWriter, r *http.Request) {
 			user.Email = email
 		}
 
+		if city != user.City {
+			_, err := db.DB.Exec("UPDATE users SET city = ? WHERE id = ?", city, user.ID)
+			if err != nil {
+				data := map[string]interface{}{"User": user, "Error": "Erreur mise à jour de la ville"}
+				templates.RenderTemplate(w, h.Templates, "profile.html", data)
+				return
+			}
+			user.City = city
+		}
+
 		if password != "" {
 			if len(password) < 6 {
 				data := map[string]interface{}{"User": user, "Error": "Mot de passe trop court"}
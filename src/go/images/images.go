@@ -0,0 +1,333 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"groupie/src/go/api"
+	"groupie/src/go/config"
+)
+
+// Size est une variante d'image servie par le proxy
+type Size struct {
+	Name   string
+	Width  int
+	Height int
+}
+
+var (
+	SizeThumb = Size{Name: "thumb", Width: 160, Height: 160}
+	SizeCard  = Size{Name: "card", Width: 480, Height: 360}
+)
+
+// Sizes liste les variantes disponibles par nom
+var Sizes = map[string]Size{
+	SizeThumb.Name: SizeThumb,
+	SizeCard.Name:  SizeCard,
+}
+
+// maxOriginalBytes borne la taille d'une image téléchargée
+const maxOriginalBytes = 10 << 20
+
+// Variant est une image redimensionnée prête à être servie
+type Variant struct {
+	Data []byte
+	ETag string
+}
+
+// Cache télécharge chaque image d'artiste une seule fois et garde ses variantes sur disque.
+// Les fichiers sont nommés d'après l'artiste et l'URL source : une nouvelle URL donne une
+// nouvelle image, et les fichiers de l'ancienne sont supprimés.
+type Cache struct {
+	Dir         string
+	Client      *api.Client
+	Placeholder string
+
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	// failures date les échecs par clé de cache ; les entrées expirées sont purgées
+	failures map[string]time.Time
+}
+
+// failureBackoff évite de retélécharger en boucle une image dont l'origine échoue
+const failureBackoff = 10 * time.Minute
+
+func NewCache(dir string, client *api.Client, placeholder string) *Cache {
+	return &Cache{
+		Dir:         dir,
+		Client:      client,
+		Placeholder: placeholder,
+		locks:       make(map[string]*sync.Mutex),
+		failures:    make(map[string]time.Time),
+	}
+}
+
+// lock sérialise le travail sur une même image pour ne la télécharger qu'une fois
+func (c *Cache) lock(key string) *sync.Mutex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.locks[key]
+	if !ok {
+		l = &sync.Mutex{}
+		c.locks[key] = l
+	}
+	return l
+}
+
+// Get retourne la variante demandée de l'image d'un artiste, en la téléchargeant et
+// en la redimensionnant au besoin.
+func (c *Cache) Get(ctx context.Context, artistID int, sourceURL string, size Size) (Variant, error) {
+	id := strconv.Itoa(artistID)
+	key := cacheKey(id, sourceURL)
+	variantPath := filepath.Join(c.Dir, size.Name, key+".jpg")
+
+	l := c.lock(id)
+	l.Lock()
+	defer l.Unlock()
+
+	if data, err := os.ReadFile(variantPath); err == nil {
+		return newVariant(data), nil
+	}
+
+	if c.recentlyFailed(key) {
+		return Variant{}, fmt.Errorf("image artiste %d: origine en échec récent", artistID)
+	}
+
+	original, err := c.original(ctx, id, key, sourceURL)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.markFailed(key)
+		}
+		return Variant{}, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(original))
+	if err != nil {
+		c.markFailed(key)
+		return Variant{}, fmt.Errorf("image artiste %d: %w", artistID, err)
+	}
+
+	data, err := encode(Resize(src, size.Width, size.Height))
+	if err != nil {
+		return Variant{}, err
+	}
+	if err := writeFile(variantPath, data); err != nil {
+		return Variant{}, err
+	}
+	return newVariant(data), nil
+}
+
+// cacheKey nomme les fichiers d'une image : identifiant de l'artiste et empreinte de l'URL source
+func cacheKey(id, sourceURL string) string {
+	sum := sha1.Sum([]byte(sourceURL))
+	return id + "-" + hex.EncodeToString(sum[:6])
+}
+
+func (c *Cache) recentlyFailed(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.failures[key]
+	if ok && time.Since(at) >= failureBackoff {
+		delete(c.failures, key)
+		return false
+	}
+	return ok
+}
+
+func (c *Cache) markFailed(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for k, at := range c.failures {
+		if now.Sub(at) >= failureBackoff {
+			delete(c.failures, k)
+		}
+	}
+	c.failures[key] = now
+}
+
+// original lit l'image source depuis le disque ou la télécharge ; un nouveau téléchargement
+// remplace les fichiers d'une ancienne URL de l'artiste
+func (c *Cache) original(ctx context.Context, id, key, sourceURL string) ([]byte, error) {
+	path := filepath.Join(c.Dir, "original", key)
+	if data, err := os.ReadFile(path); err == nil {
+		return data, nil
+	}
+	if sourceURL == "" {
+		return nil, errors.New("image: url source vide")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.UserAgentValue)
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("image %s: statut %d", sourceURL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOriginalBytes))
+	if err != nil {
+		return nil, err
+	}
+	// Ne garder que des images décodables
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("image %s: %w", sourceURL, err)
+	}
+	c.removeStale(id, key)
+	if err := writeFile(path, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// removeStale supprime l'original et les variantes d'un artiste obtenus d'une autre URL
+func (c *Cache) removeStale(id, key string) {
+	dirs := []string{"original"}
+	for name := range Sizes {
+		dirs = append(dirs, name)
+	}
+	for _, dir := range dirs {
+		// id et id.jpg : fichiers nommés avant l'empreinte de l'URL
+		matches, _ := filepath.Glob(filepath.Join(c.Dir, dir, id+"-*"))
+		matches = append(matches, filepath.Join(c.Dir, dir, id), filepath.Join(c.Dir, dir, id+".jpg"))
+		for _, path := range matches {
+			if name := filepath.Base(path); name != key && name != key+".jpg" {
+				os.Remove(path)
+			}
+		}
+	}
+}
+
+// PlaceholderVariant retourne l'image de remplacement à la taille demandée
+func (c *Cache) PlaceholderVariant(size Size) Variant {
+	key := "placeholder-" + size.Name
+	path := filepath.Join(c.Dir, size.Name, key+".jpg")
+
+	l := c.lock(key)
+	l.Lock()
+	defer l.Unlock()
+
+	if data, err := os.ReadFile(path); err == nil {
+		return newVariant(data)
+	}
+
+	var src image.Image
+	if f, err := os.Open(c.Placeholder); err == nil {
+		src, _, _ = image.Decode(f)
+		f.Close()
+	}
+	if src == nil {
+		img := image.NewRGBA(image.Rect(0, 0, size.Width, size.Height))
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: color.RGBA{R: 40, G: 30, B: 60, A: 255}}, image.Point{}, draw.Src)
+		src = img
+	}
+
+	data, err := encode(Resize(src, size.Width, size.Height))
+	if err != nil {
+		return Variant{}
+	}
+	_ = writeFile(path, data)
+	return newVariant(data)
+}
+
+func newVariant(data []byte) Variant {
+	sum := sha1.Sum(data)
+	return Variant{Data: data, ETag: `"` + hex.EncodeToString(sum[:]) + `"`}
+}
+
+func encode(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile écrit un fichier de cache de façon atomique
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Resize recadre l'image au ratio demandé (centré) puis la réduit par moyenne des pixels
+func Resize(src image.Image, width, height int) image.Image {
+	b := src.Bounds()
+	srcW, srcH := b.Dx(), b.Dy()
+
+	// Zone source centrée au ratio cible
+	cropW, cropH := srcW, srcW*height/width
+	if cropH > srcH {
+		cropW, cropH = srcH*width/height, srcH
+	}
+	x0 := b.Min.X + (srcW-cropW)/2
+	y0 := b.Min.Y + (srcH-cropH)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if cropW == 0 || cropH == 0 {
+		return dst
+	}
+
+	for y := 0; y < height; y++ {
+		sy0 := y0 + y*cropH/height
+		sy1 := y0 + (y+1)*cropH/height
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < width; x++ {
+			sx0 := x0 + x*cropW/width
+			sx1 := x0 + (x+1)*cropW/width
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r += uint64(cr)
+					g += uint64(cg)
+					bl += uint64(cb)
+					a += uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,83 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"groupie/src/go/api"
+)
+
+func solidPNG(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for y := range 8 {
+		for x := range 8 {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCacheFollowsSourceURL(t *testing.T) {
+	red, blue := solidPNG(t, color.RGBA{R: 255, A: 255}), solidPNG(t, color.RGBA{B: 255, A: 255})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/red.png":
+			w.Write(red)
+		case "/blue.png":
+			w.Write(blue)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	c := NewCache(dir, api.NewClient(srv.Client()), "")
+	ctx := context.Background()
+
+	first, err := c.Get(ctx, 7, srv.URL+"/red.png", SizeThumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.Get(ctx, 7, srv.URL+"/blue.png", SizeThumb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.ETag == second.ETag {
+		t.Fatal("nouvelle URL source servie avec l'ancienne image")
+	}
+
+	for _, sub := range []string{"original", SizeThumb.Name} {
+		entries, _ := os.ReadDir(filepath.Join(dir, sub))
+		if len(entries) != 1 {
+			t.Errorf("%s: %d fichiers, attendu 1 (ancienne image supprimée)", sub, len(entries))
+		}
+	}
+}
+
+func TestCacheFailuresExpire(t *testing.T) {
+	c := NewCache(t.TempDir(), nil, "")
+	c.failures["ancien"] = time.Now().Add(-2 * failureBackoff)
+	c.markFailed("nouveau")
+
+	if _, ok := c.failures["ancien"]; ok {
+		t.Error("échec expiré conservé")
+	}
+	if !c.recentlyFailed("nouveau") {
+		t.Error("échec récent ignoré")
+	}
+}
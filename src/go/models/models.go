@@ -3,7 +3,7 @@ package models
 import (
 	"html/template"
 	"net/http"
-	"sync"
+	"net/url"
 	"time"
 )
 
@@ -16,6 +16,7 @@ type User struct {
 	Avatar    string    `json:"avatar"`
 	IsAdmin   bool      `json:"is_admin"`
 	IsOwner   bool      `json:"is_owner"`
+	City      string    `json:"city"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -31,16 +32,40 @@ type RegisterCredentials struct {
 }
 
 type Artist struct {
-	ID             int       `json:"id"`
-	Name           string    `json:"name"`
-	Image          string    `json:"image"`
-	Members        []string  `json:"members"`
-	CreationDate   int       `json:"creationDate"`
-	FirstAlbum     string    `json:"firstAlbum"`
-	FirstAlbumYear int       `json:"firstAlbumYear"`
-	Concerts       []Concert `json:"concerts"`
-	Tags           []string  `json:"tags"`
-	IsFavorite     bool      `json:"isFavorite"`
+	ID             int            `json:"id"`
+	Name           string         `json:"name"`
+	Image          string         `json:"image"`
+	Members        []string       `json:"members"`
+	CreationDate   int            `json:"creationDate"`
+	FirstAlbum     string         `json:"firstAlbum"`
+	FirstAlbumYear int            `json:"firstAlbumYear"`
+	Concerts       []Concert      `json:"concerts"`
+	Events         []ConcertEvent `json:"events"`
+	Tags           []string       `json:"tags"`
+	IsFavorite     bool           `json:"isFavorite"`
+	Genres         []string       `json:"genres,omitempty"`
+	Biography      string         `json:"biography,omitempty"`
+	Links          []ArtistLink   `json:"links,omitempty"`
+	MemberDetails  []MemberInfo   `json:"memberDetails,omitempty"`
+}
+
+// ArtistMeta enrichit un artiste depuis le fichier d'overlay local (clé: ID de l'artiste)
+type ArtistMeta struct {
+	Genres    []string     `json:"genres"`
+	Biography string       `json:"biography"`
+	Links     []ArtistLink `json:"links"`
+	Members   []MemberInfo `json:"members"`
+}
+
+type ArtistLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+type MemberInfo struct {
+	Name        string   `json:"name"`
+	Role        string   `json:"role,omitempty"`
+	Instruments []string `json:"instruments,omitempty"`
 }
 
 type Concert struct {
@@ -50,11 +75,65 @@ type Concert struct {
 	Coordinates     *Coordinates `json:"coordinates,omitempty"`
 }
 
+// ConcertEvent est un concert unique (un lieu, une date), trié chronologiquement dans Artist.Events
+type ConcertEvent struct {
+	Location        string       `json:"location"`
+	DisplayLocation string       `json:"displayLocation"`
+	Date            time.Time    `json:"date"`
+	Coordinates     *Coordinates `json:"coordinates,omitempty"`
+}
+
+// ArtistEvent associe un concert à son artiste pour les listes transverses
+type ArtistEvent struct {
+	ArtistID   int    `json:"artistId"`
+	ArtistName string `json:"artistName"`
+	ConcertEvent
+}
+
 type Coordinates struct {
 	Latitude  float64 `json:"lat"`
 	Longitude float64 `json:"lng"`
 }
 
+// ArtistCoordinates répond à /api/artist/{id}/coordinates : coordonnées par lieu brut
+// (Concert.Location), lieux à redemander plus tard (Nominatim saturé) et lieux introuvables
+type ArtistCoordinates struct {
+	ArtistID    int                    `json:"artistId"`
+	Coordinates map[string]Coordinates `json:"coordinates"`
+	Pending     []string               `json:"pending"`
+	Failed      []string               `json:"failed"`
+}
+
+// GeocodeProgress est l'avancement du géocodage en arrière-plan des lieux sans coordonnées
+type GeocodeProgress struct {
+	Queued   int    `json:"queued"` // lieux mis en file depuis le démarrage
+	Resolved int    `json:"resolved"`
+	Failed   int    `json:"failed"`
+	Pending  int    `json:"pending"`
+	Current  string `json:"current,omitempty"`
+	// Missing compte les concerts encore sans coordonnées dans le jeu de données publié
+	Missing int `json:"missing"`
+}
+
+// Origines d'une entrée du cache de géocodage
+const (
+	GeoSourceVenue     = "venue"
+	GeoSourceGazetteer = "gazetteer"
+	GeoSourceNominatim = "nominatim"
+	GeoSourceManual    = "manual"
+)
+
+// GeocodeEntry est un résultat de géocodage mis en cache. Sans coordonnées, c'est un échec
+// (cache négatif) dont Error donne la raison.
+type GeocodeEntry struct {
+	Key         string       `json:"key"`
+	Query       string       `json:"query"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"`
+	Source      string       `json:"source"`
+	Error       string       `json:"error,omitempty"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
 type APIIndex struct {
 	ArtistsURL   string `json:"artists"`
 	LocationsURL string `json:"locations"`
@@ -79,14 +158,221 @@ type APIRelation struct {
 	DatesLocations map[string][]string `json:"datesLocations"`
 }
 
+type APILocation struct {
+	ID        int      `json:"id"`
+	Locations []string `json:"locations"`
+	DatesURL  string   `json:"dates"`
+}
+
+type APIDate struct {
+	ID    int      `json:"id"`
+	Dates []string `json:"dates"`
+}
+
+// DataIssue signale une incohérence entre les sources relations, lieux et dates
+type DataIssue struct {
+	ArtistID   int      `json:"artistId"`
+	ArtistName string   `json:"artistName"`
+	Kind       string   `json:"kind"`
+	Location   string   `json:"location,omitempty"`
+	Values     []string `json:"values,omitempty"`
+	Repaired   bool     `json:"repaired"`
+}
+
+// QualityReport regroupe les incohérences du dernier rafraîchissement
+type QualityReport struct {
+	GeneratedAt time.Time   `json:"generatedAt"`
+	Checked     int         `json:"checked"`
+	Repaired    int         `json:"repaired"`
+	Issues      []DataIssue `json:"issues"`
+}
+
 type App struct {
-	Client     *http.Client
-	Templates  map[string]*template.Template
-	Mu         sync.RWMutex
-	Artists    []Artist
-	Locations  []string
-	GeoCache   map[string]Coordinates
-	GeoCacheMu sync.RWMutex
+	Client    *http.Client
+	Templates map[string]*template.Template
+}
+
+// DataSnapshot est la copie sur disque du jeu de données, rechargée au démarrage
+type DataSnapshot struct {
+	Version   int       `json:"version"`
+	SavedAt   time.Time `json:"savedAt"`
+	Artists   []Artist  `json:"artists"`
+	Locations []string  `json:"locations"`
+}
+
+// RefreshReport résume le dernier rafraîchissement des données
+type RefreshReport struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Total      int       `json:"total"`
+	Succeeded  int       `json:"succeeded"`
+	Failed     int       `json:"failed"`
+	Retried    int       `json:"retried"`
+	Error      string    `json:"error,omitempty"`
+	// RetryAfterMs est l'attente demandée par l'API (Retry-After) avant un nouvel essai
+	RetryAfterMs int64                 `json:"retryAfterMs,omitempty"`
+	Artists      []ArtistRefreshResult `json:"artists"`
+}
+
+// RefreshJob suit un rafraîchissement lancé en arrière-plan.
+// Status vaut "running", "done" ou "failed".
+type RefreshJob struct {
+	ID         string         `json:"id"`
+	Trigger    string         `json:"trigger"`
+	Status     string         `json:"status"`
+	StartedAt  time.Time      `json:"startedAt"`
+	FinishedAt *time.Time     `json:"finishedAt,omitempty"`
+	Done       int            `json:"done"`
+	Total      int            `json:"total"`
+	Error      string         `json:"error,omitempty"`
+	Report     *RefreshReport `json:"report,omitempty"`
+}
+
+// ArtistRefreshResult détaille le chargement d'un artiste.
+// Status vaut "ok", "kept" (échec, anciennes données conservées) ou "failed".
+type ArtistRefreshResult struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Attempts   int    `json:"attempts"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DataChange liste les différences constatées lors d'un rafraîchissement
+type DataChange struct {
+	At              time.Time       `json:"at"`
+	ArtistsAdded    []ArtistRef     `json:"artistsAdded,omitempty"`
+	ArtistsRemoved  []ArtistRef     `json:"artistsRemoved,omitempty"`
+	ConcertsAdded   []ConcertChange `json:"concertsAdded,omitempty"`
+	ConcertsRemoved []ConcertChange `json:"concertsRemoved,omitempty"`
+	DatesAdded      []ConcertChange `json:"datesAdded,omitempty"`
+	DatesRemoved    []ConcertChange `json:"datesRemoved,omitempty"`
+}
+
+type ArtistRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ConcertChange struct {
+	ArtistID   int      `json:"artistId"`
+	ArtistName string   `json:"artistName"`
+	Location   string   `json:"location"`
+	Dates      []string `json:"dates"`
+}
+
+// ListOptions décrit le tri, la pagination et les champs demandés sur une liste d'artistes
+type ListOptions struct {
+	Sort   string
+	Desc   bool
+	Offset int
+	Limit  int
+	Cursor string
+	Fields []string
+}
+
+// ArtistPage est une page d'artistes ; Items contient des artistes complets ou réduits aux champs demandés
+type ArtistPage struct {
+	Items      interface{} `json:"items"`
+	Total      int         `json:"total"`
+	Offset     int         `json:"offset"`
+	Limit      int         `json:"limit"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+}
+
+// NearbyConcert est un concert retenu par le filtre géographique, avec sa distance au centre
+type NearbyConcert struct {
+	ArtistEvent
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+// FilterResult est la réponse enrichie de /api/filter (filtre géographique ou facettes) ;
+// Artists contient la liste ou la page d'artistes habituelle
+type FilterResult struct {
+	Artists  interface{}     `json:"artists"`
+	Center   *Coordinates    `json:"center,omitempty"`
+	RadiusKm float64         `json:"radiusKm,omitempty"`
+	Concerts []NearbyConcert `json:"concerts,omitempty"`
+	Facets   *Facets         `json:"facets,omitempty"`
+}
+
+// SearchResult est une correspondance de recherche, ex: "Freddie Mercury — membre de Queen"
+type SearchResult struct {
+	Type       string  `json:"type"`
+	Label      string  `json:"label"`
+	Value      string  `json:"value"`
+	ArtistID   int     `json:"artistId"`
+	ArtistName string  `json:"artistName"`
+	Score      float64 `json:"score"`
+}
+
+type SearchGroup struct {
+	Type    string         `json:"type"`
+	Label   string         `json:"label"`
+	Results []SearchResult `json:"results"`
+}
+
+type SearchResponse struct {
+	Query  string        `json:"query"`
+	Total  int           `json:"total"`
+	Groups []SearchGroup `json:"groups"`
+}
+
+// SavedSearch est un filtre enregistré par un utilisateur ; Criteria est le FilterCriteria
+// sérialisé en query string de /api/filter (voir game.EncodeFilterCriteria)
+type SavedSearch struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"-"`
+	Name      string    `json:"name"`
+	Criteria  string    `json:"criteria"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SearchMatch est un artiste ou un concert retenu par une recherche enregistrée ;
+// Key l'identifie d'un rafraîchissement à l'autre
+type SearchMatch struct {
+	Key        string
+	Kind       string
+	ArtistID   int
+	ArtistName string
+	Location   string
+	Date       time.Time
+}
+
+// Notification signale un nouvel artiste ou concert correspondant à une recherche enregistrée
+type Notification struct {
+	ID         int        `json:"id"`
+	SearchID   int        `json:"searchId"`
+	SearchName string     `json:"searchName"`
+	Kind       string     `json:"kind"`
+	ArtistID   int        `json:"artistId"`
+	ArtistName string     `json:"artistName"`
+	Location   string     `json:"location,omitempty"`
+	Date       *time.Time `json:"date,omitempty"`
+	Message    string     `json:"message"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	Read       bool       `json:"read"`
+}
+
+// QueryPhrase est un morceau de requête en langage naturel compris par le parseur,
+// affiché comme une puce amovible ; Params sont les paramètres de /api/filter équivalents
+type QueryPhrase struct {
+	Text   string     `json:"text"`
+	Kind   string     `json:"kind"`
+	Label  string     `json:"label"`
+	Params url.Values `json:"params"`
+}
+
+// ParsedQuery est le résultat de l'analyse d'une requête, ex: "groupes des années 90 qui jouent en Allemagne".
+// Filter est la query string de /api/filter réunissant toutes les expressions ; Remainder le texte non compris.
+type ParsedQuery struct {
+	Query     string         `json:"query"`
+	Phrases   []QueryPhrase  `json:"phrases"`
+	Remainder string         `json:"remainder"`
+	Filter    string         `json:"filter"`
+	Criteria  FilterCriteria `json:"-"`
 }
 
 type FilterMeta struct {
@@ -97,6 +383,34 @@ type FilterMeta struct {
 	MembersMin  int
 	MembersMax  int
 	Locations   []string
+	Countries   []string
+	// Tranches des facettes de /api/filter
+	MemberBuckets   []FacetRange
+	CreationDecades []FacetRange
+	AlbumDecades    []FacetRange
+}
+
+// FacetRange est une valeur de facette ; Min et Max (inclus) bornent les facettes numériques
+type FacetRange struct {
+	Key   string `json:"key"`
+	Label string `json:"label"`
+	Min   int    `json:"min,omitempty"`
+	Max   int    `json:"max,omitempty"`
+}
+
+// FacetCount est le nombre d'artistes qu'il resterait en choisissant cette valeur
+type FacetCount struct {
+	FacetRange
+	Count int `json:"count"`
+}
+
+// Facets regroupe les comptes par dimension ; chaque dimension ignore son propre critère
+type Facets struct {
+	Locations       []FacetCount `json:"locations"`
+	Countries       []FacetCount `json:"countries"`
+	Members         []FacetCount `json:"members"`
+	CreationDecades []FacetCount `json:"creationDecades"`
+	AlbumDecades    []FacetCount `json:"albumDecades"`
 }
 
 type FilterCriteria struct {
@@ -106,7 +420,26 @@ type FilterCriteria struct {
 	AlbumMax    int
 	MembersMin  int
 	MembersMax  int
-	Locations   map[string]struct{}
+	// Lieux normalisés avec utils.FoldText : "Paris, France" ou "paris-france"
+	Locations map[string]struct{}
+
+	// Période de concerts (bornes incluses, zéro = non bornée)
+	ConcertFrom time.Time
+	ConcertTo   time.Time
+	// Pays normalisés avec utils.FoldText (ex: "new zealand")
+	Countries map[string]struct{}
+	// Member est une partie de nom de membre, normalisée
+	Member       string
+	UpcomingOnly bool
+	// FavoritesOnly restreint aux artistes de Favorites, rempli par le handler pour l'utilisateur connecté
+	FavoritesOnly bool
+	Favorites     map[int]struct{}
+	// Center limite aux concerts à moins de RadiusKm km (nil = pas de limite géographique).
+	// NearMe demande de centrer sur la ville du profil : le handler renseigne alors Center.
+	Center   *Coordinates
+	RadiusKm float64
+	NearMe   bool
+	Now      time.Time
 }
 
 type HomePageData struct {
@@ -118,4 +451,3 @@ type ArtistPageData struct {
 	Artist     Artist
 	ArtistJSON template.JS
 }
-
@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"groupie/src/go/config"
+	"groupie/src/go/game"
+	"groupie/src/go/models"
+)
+
+// SearchStore donne accès aux recherches enregistrées et à leurs correspondances
+// (voir db.SearchStore)
+type SearchStore interface {
+	AllSavedSearches() ([]models.SavedSearch, error)
+	FavoriteIDs(userID int) (map[int]struct{}, error)
+	SeenMatchKeys(searchID int) (map[string]struct{}, error)
+	RecordMatches(searchID, userID int, matches []models.SearchMatch, notifications []models.Notification) error
+}
+
+// Notifier évalue les recherches enregistrées hors du chemin du rafraîchissement : au plus
+// une évaluation à la fois, la dernière liste d'artistes reçue pendant qu'elle tourne est
+// évaluée ensuite. Enqueue s'enregistre avec DataService.OnRefresh.
+type Notifier struct {
+	Store SearchStore
+	// Notify évalue une liste d'artistes ; NotifyAll par défaut
+	Notify  func(ctx context.Context, artists []models.Artist)
+	Timeout time.Duration
+
+	mu      sync.Mutex
+	pending []models.Artist
+	running bool
+}
+
+func NewNotifier(store SearchStore) *Notifier {
+	n := &Notifier{
+		Store:   store,
+		Timeout: config.NotifyTimeout,
+	}
+	n.Notify = n.NotifyAll
+	return n
+}
+
+// Enqueue programme l'évaluation de artists ; RefreshData n'attend pas la base de données
+func (n *Notifier) Enqueue(artists []models.Artist) {
+	n.mu.Lock()
+	n.pending = artists
+	if n.running {
+		n.mu.Unlock()
+		return
+	}
+	n.running = true
+	n.mu.Unlock()
+	go n.drain()
+}
+
+func (n *Notifier) drain() {
+	for {
+		n.mu.Lock()
+		artists := n.pending
+		n.pending = nil
+		if artists == nil {
+			n.running = false
+			n.mu.Unlock()
+			return
+		}
+		n.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), n.Timeout)
+		n.Notify(ctx, artists)
+		cancel()
+	}
+}
+
+// NotifyAll évalue toutes les recherches enregistrées après un rafraîchissement ;
+// les recherches restantes sont abandonnées à l'annulation de ctx
+func (n *Notifier) NotifyAll(ctx context.Context, artists []models.Artist) {
+	searches, err := n.Store.AllSavedSearches()
+	if err != nil {
+		log.Printf("recherches enregistrées: %v", err)
+		return
+	}
+	created := 0
+	for i, search := range searches {
+		if err := ctx.Err(); err != nil {
+			log.Printf("recherches enregistrées: %d sur %d non évaluées: %v", len(searches)-i, len(searches), err)
+			break
+		}
+		count, err := n.Evaluate(search, artists, true)
+		if err != nil {
+			log.Printf("recherche %d: %v", search.ID, err)
+			continue
+		}
+		created += count
+	}
+	if created > 0 {
+		log.Printf("Recherches enregistrées: %d notification(s) créée(s)", created)
+	}
+}
+
+// Evaluate enregistre les nouvelles correspondances d'une recherche et retourne le nombre
+// de notifications créées. Sans notify, elles sont seulement marquées comme vues (référence initiale).
+func (n *Notifier) Evaluate(search models.SavedSearch, artists []models.Artist, notify bool) (int, error) {
+	criteria, err := game.DecodeFilterCriteria(search.Criteria)
+	if err != nil {
+		return 0, err
+	}
+	if criteria.FavoritesOnly {
+		favorites, err := n.Store.FavoriteIDs(search.UserID)
+		if err != nil {
+			return 0, err
+		}
+		criteria.Favorites = favorites
+	}
+
+	seen, err := n.Store.SeenMatchKeys(search.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	var fresh []models.SearchMatch
+	for _, m := range game.SavedSearchMatches(artists, criteria) {
+		if _, ok := seen[m.Key]; !ok {
+			fresh = append(fresh, m)
+		}
+	}
+
+	var notifications []models.Notification
+	if notify {
+		notifications = buildNotifications(search, fresh)
+	}
+	if err := n.Store.RecordMatches(search.ID, search.UserID, fresh, notifications); err != nil {
+		return 0, err
+	}
+	return len(notifications), nil
+}
+
+// buildNotifications crée une notification par correspondance ; les concerts d'un artiste
+// qui vient d'entrer dans la recherche sont couverts par la notification de l'artiste
+func buildNotifications(search models.SavedSearch, matches []models.SearchMatch) []models.Notification {
+	newArtists := map[int]bool{}
+	for _, m := range matches {
+		if m.Kind == game.MatchArtist {
+			newArtists[m.ArtistID] = true
+		}
+	}
+
+	var out []models.Notification
+	for _, m := range matches {
+		n := models.Notification{
+			SearchID:   search.ID,
+			SearchName: search.Name,
+			Kind:       m.Kind,
+			ArtistID:   m.ArtistID,
+			ArtistName: m.ArtistName,
+		}
+		switch m.Kind {
+		case game.MatchArtist:
+			n.Message = fmt.Sprintf("%s correspond à votre recherche « %s »", m.ArtistName, search.Name)
+		case game.MatchConcert:
+			if newArtists[m.ArtistID] {
+				continue
+			}
+			date := m.Date
+			n.Location = m.Location
+			n.Date = &date
+			n.Message = fmt.Sprintf("%s annonce une date à %s le %s", m.ArtistName, m.Location, m.Date.Format("02/01/2006"))
+		}
+		out = append(out, n)
+	}
+	return out
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"groupie/src/go/game"
+	"groupie/src/go/models"
+)
+
+// memoryStore garde en mémoire les correspondances vues et les notifications créées
+type memoryStore struct {
+	favorites     map[int]struct{}
+	seen          map[int]map[string]struct{}
+	notifications []models.Notification
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{seen: map[int]map[string]struct{}{}}
+}
+
+func (s *memoryStore) AllSavedSearches() ([]models.SavedSearch, error) { return nil, nil }
+
+func (s *memoryStore) FavoriteIDs(userID int) (map[int]struct{}, error) { return s.favorites, nil }
+
+func (s *memoryStore) SeenMatchKeys(searchID int) (map[string]struct{}, error) {
+	return maps.Clone(s.seen[searchID]), nil
+}
+
+func (s *memoryStore) RecordMatches(searchID, userID int, matches []models.SearchMatch, notifications []models.Notification) error {
+	if s.seen[searchID] == nil {
+		s.seen[searchID] = map[string]struct{}{}
+	}
+	for _, m := range matches {
+		s.seen[searchID][m.Key] = struct{}{}
+	}
+	s.notifications = append(s.notifications, notifications...)
+	return nil
+}
+
+// TestNotifierCoalesces vérifie qu'une seule évaluation tourne à la fois et que seule la
+// dernière liste reçue pendant qu'elle tourne est évaluée ensuite
+func TestNotifierCoalesces(t *testing.T) {
+	started := make(chan int, 4)
+	release := make(chan struct{})
+	n := NewNotifier(nil)
+	n.Notify = func(ctx context.Context, artists []models.Artist) {
+		started <- artists[0].ID
+		<-release
+	}
+
+	n.Enqueue([]models.Artist{{ID: 1}})
+	if id := <-started; id != 1 {
+		t.Fatalf("première évaluation = %d, attendu 1", id)
+	}
+	n.Enqueue([]models.Artist{{ID: 2}})
+	n.Enqueue([]models.Artist{{ID: 3}})
+	close(release)
+
+	if id := <-started; id != 3 {
+		t.Errorf("deuxième évaluation = %d, attendu 3 (la dernière liste)", id)
+	}
+	select {
+	case id := <-started:
+		t.Errorf("évaluation en trop : %d", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.running || n.pending != nil {
+		t.Errorf("running = %v, pending = %v ; attendu au repos", n.running, n.pending)
+	}
+}
+
+// testArtist crée un artiste avec un concert par date, au même lieu
+func testArtist(id int, location string, dates ...string) models.Artist {
+	art := models.Artist{ID: id, Name: fmt.Sprint("artiste ", id)}
+	art.Concerts = []models.Concert{{Location: location, DisplayLocation: location, Dates: dates}}
+	for _, d := range dates {
+		date, _ := time.Parse("2006-01-02", d)
+		art.Events = append(art.Events, models.ConcertEvent{Location: location, DisplayLocation: location, Date: date})
+	}
+	return art
+}
+
+// TestEvaluate enchaîne les évaluations d'une recherche sur un même magasin : seules les
+// correspondances nouvelles depuis l'évaluation précédente notifient
+func TestEvaluate(t *testing.T) {
+	store := newMemoryStore()
+	store.favorites = map[int]struct{}{2: {}}
+	n := NewNotifier(store)
+	france := models.SavedSearch{ID: 1, UserID: 7, Name: "France", Criteria: "country=france"}
+	favorites := models.SavedSearch{ID: 2, UserID: 7, Name: "Favoris", Criteria: "country=france&favorites=1"}
+
+	a := testArtist(1, "paris-france", "2030-01-01")
+	aMore := testArtist(1, "paris-france", "2030-01-01", "2030-02-01")
+	b := testArtist(2, "lyon-france", "2030-03-01", "2030-04-01")
+	c := testArtist(3, "london-uk", "2030-05-01")
+
+	steps := []struct {
+		name    string
+		search  models.SavedSearch
+		artists []models.Artist
+		notify  bool
+		want    []string // "type artiste" des notifications créées
+	}{
+		{"référence initiale : rien n'est notifié", france, []models.Artist{a}, false, nil},
+		{"rien de nouveau", france, []models.Artist{a}, true, nil},
+		{"nouveau concert d'un artiste connu", france, []models.Artist{aMore}, true, []string{"concert 1"}},
+		// Les concerts d'un nouvel artiste sont couverts par sa notification
+		{"nouvel artiste", france, []models.Artist{aMore, b}, true, []string{"artist 2"}},
+		{"hors critères", france, []models.Artist{aMore, b, c}, true, nil},
+		{"artiste hors critères retiré", france, []models.Artist{aMore, b}, true, nil},
+		{"favoris seulement", favorites, []models.Artist{aMore, b, c}, true, []string{"artist 2"}},
+	}
+	for _, step := range steps {
+		before := len(store.notifications)
+		count, err := n.Evaluate(step.search, step.artists, step.notify)
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		var got []string
+		for _, notif := range store.notifications[before:] {
+			if notif.SearchID != step.search.ID || notif.Message == "" {
+				t.Errorf("%s: notification incomplète %+v", step.name, notif)
+			}
+			got = append(got, fmt.Sprintf("%s %d", notif.Kind, notif.ArtistID))
+		}
+		if count != len(got) || !slices.Equal(got, step.want) {
+			t.Errorf("%s: %d notifications %q, attendu %q", step.name, count, got, step.want)
+		}
+	}
+
+	if _, err := n.Evaluate(models.SavedSearch{ID: 3, Criteria: "%zz"}, []models.Artist{a}, true); err == nil {
+		t.Error("critères illisibles : erreur attendue")
+	}
+	if first := store.notifications[0]; first.Kind != game.MatchConcert || first.Date == nil || first.Location != "paris-france" {
+		t.Errorf("notification de concert incomplète : %+v", first)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"groupie/src/go/handlers/checkout"
 	"groupie/src/go/handlers/dashboard"
 	"groupie/src/go/handlers/home"
+	"groupie/src/go/handlers/image"
 	"groupie/src/go/handlers/landing"
+	"groupie/src/go/handlers/searches"
 	"groupie/src/go/handlers/user"
 	"groupie/src/go/middleware"
 )
@@ -24,17 +26,20 @@ func SetupRoutes() (http.Handler, error) {
 
 	landingHandler := landing.NewLandingHandler(appInit.Templates)
 	authHandler := auth.NewAuthHandler(appInit.Templates)
-	homeHandler := home.NewHomeHandler(appInit.DataService, appInit.Templates)
-	artistHandler := artist.NewArtistHandler(appInit.DataService, appInit.GeocodeService, appInit.Templates)
-	apiHandler := api.NewAPIHandler(appInit.DataService, appInit.GeocodeService)
+	homeHandler := home.NewHomeHandler(appInit.DataService, appInit.GeocodeService, appInit.Templates)
+	artistHandler := artist.NewArtistHandler(appInit.DataService, appInit.GeocodeService, appInit.GeocodeQueue, appInit.Templates)
+	apiHandler := api.NewAPIHandler(appInit.DataService, appInit.GeocodeService, appInit.GeocodeQueue, appInit.Gazetteer, appInit.APIClient)
 	userHandler := user.NewUserHandler(appInit.Templates)
 	cartHandler := cart.NewCartHandler()
 	dashboardHandler := dashboard.NewDashboardHandler(appInit.Templates)
 	checkoutHandler := checkout.NewCheckoutHandler(appInit.Templates)
+	imageHandler := image.NewImageHandler(appInit.DataService, appInit.ImageCache)
+	searchesHandler := searches.NewSearchesHandler(appInit.DataService, appInit.Notifier)
 
 	mux := http.NewServeMux()
 	mux.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.Dir("assets"))))
 	mux.Handle("/script/", http.StripPrefix("/script/", http.FileServer(http.Dir("src/js"))))
+	mux.HandleFunc("/img/artist/", imageHandler.Handle)
 	mux.HandleFunc("/", landingHandler.Handle)
 	mux.HandleFunc("/login", authHandler.HandleLogin)
 	mux.HandleFunc("/register", authHandler.HandleRegister)
@@ -42,14 +47,26 @@ func SetupRoutes() (http.Handler, error) {
 	mux.HandleFunc("/profile", userHandler.HandleProfile)
 	mux.HandleFunc("/home", homeHandler.Handle)
 	mux.HandleFunc("/artist", artistHandler.Handle)
+	mux.HandleFunc("/api/artist/", artistHandler.HandleCoordinates)
 	mux.HandleFunc("/dashboard", dashboardHandler.Handle)
 	mux.HandleFunc("/checkout", checkoutHandler.Handle)
 	mux.HandleFunc("/terms", landingHandler.HandleTerms)
 	mux.HandleFunc("/api/artists", apiHandler.HandleArtists)
 	mux.HandleFunc("/api/filter", apiHandler.HandleFilter)
 	mux.HandleFunc("/api/refresh", apiHandler.HandleRefresh)
+	mux.HandleFunc("/api/refresh/", apiHandler.HandleRefreshJob)
+	mux.HandleFunc("/api/search", apiHandler.HandleSearch)
+	mux.HandleFunc("/api/search/parse", apiHandler.HandleParseQuery)
+	mux.HandleFunc("/api/events", apiHandler.HandleEvents)
+	mux.HandleFunc("/api/events/next", apiHandler.HandleNextEvents)
+	mux.HandleFunc("/api/changes", apiHandler.HandleChanges)
 	mux.HandleFunc("/api/geocode", apiHandler.HandleGeocode)
+	mux.HandleFunc("/api/geocode/progress", apiHandler.HandleGeocodeProgress)
 	mux.HandleFunc("/api/user/favorite", userHandler.HandleToggleFavorite)
+	mux.HandleFunc("/api/searches", searchesHandler.HandleSearches)
+	mux.HandleFunc("/api/searches/", searchesHandler.HandleSearch)
+	mux.HandleFunc("/api/notifications", searchesHandler.HandleNotifications)
+	mux.HandleFunc("/api/notifications/read", searchesHandler.HandleMarkRead)
 	mux.HandleFunc("/api/cart/add", cartHandler.HandleAddItem)
 	mux.HandleFunc("/api/cart", cartHandler.HandleGetCart)
 	mux.HandleFunc("/api/cart/count", cartHandler.HandleGetCartCount)
@@ -60,6 +77,12 @@ func SetupRoutes() (http.Handler, error) {
 	mux.HandleFunc("/api/payment/return", checkoutHandler.HandlePaymentReturn)
 	mux.HandleFunc("/api/payment/demo", checkoutHandler.HandleDemoPayment)
 	mux.HandleFunc("/api/admin/toggle", userHandler.HandleToggleAdmin)
+	mux.HandleFunc("/api/admin/refresh-report", apiHandler.HandleRefreshReport)
+	mux.HandleFunc("/api/admin/upstream", apiHandler.HandleUpstreamStatus)
+	mux.HandleFunc("/api/admin/data-quality", apiHandler.HandleDataQuality)
+	mux.HandleFunc("/api/admin/overlay/reload", apiHandler.HandleReloadOverlay)
+	mux.HandleFunc("/api/admin/geocode", apiHandler.HandleGeocodeCache)
+	mux.HandleFunc("/api/admin/gazetteer", apiHandler.HandleGazetteer)
 
 	return middleware.LoggingMiddleware(mux), nil
 }
@@ -56,10 +56,10 @@ func GetUserFromRequest(r *http.Request) (*models.User, error) {
 	var expiresAt time.Time
 
 	err = db.DB.QueryRow(`
-		SELECT u.id, u.email, u.first_name, u.last_name, u.avatar, u.is_admin, u.is_owner, s.expires_at 
+		SELECT u.id, u.email, u.first_name, u.last_name, u.avatar, u.is_admin, u.is_owner, u.city, s.expires_at 
 		FROM users u 
 		JOIN sessions s ON u.id = s.user_id 
-		WHERE s.token = ?`, token).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Avatar, &user.IsAdmin, &user.IsOwner, &expiresAt)
+		WHERE s.token = ?`, token).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Avatar, &user.IsAdmin, &user.IsOwner, &user.City, &expiresAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
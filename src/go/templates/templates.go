@@ -6,8 +6,10 @@ import (
 	"html/template"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
+
+	"groupie/src/go/game"
+	"groupie/src/go/utils"
 )
 
 func GetFuncMap() template.FuncMap {
@@ -47,22 +49,16 @@ func GetFuncMap() template.FuncMap {
 			}
 		},
 		"formatDate": func(dateStr, format string) string {
-			if len(dateStr) < 10 {
-				return dateStr
-			}
-			parts := strings.Split(dateStr, "-")
-			if len(parts) != 3 {
+			t, ok := utils.ParseDate(dateStr)
+			if !ok {
 				return dateStr
 			}
 			switch format {
 			case "02":
-				return parts[2]
+				return t.Format("02")
 			case "Jan":
 				months := []string{"", "Jan", "Fév", "Mar", "Avr", "Mai", "Jun", "Jul", "Aoû", "Sep", "Oct", "Nov", "Déc"}
-				if month, err := strconv.Atoi(parts[1]); err == nil && month >= 1 && month <= 12 {
-					return months[month]
-				}
-				return parts[1]
+				return months[t.Month()]
 			default:
 				return dateStr
 			}
@@ -90,6 +86,7 @@ func GetFuncMap() template.FuncMap {
 			}
 			return af * bf
 		},
+		"memberRole": game.MemberRole,
 		"default": func(defaultVal, val interface{}) interface{} {
 			if val == nil || val == "" {
 				return defaultVal
@@ -2,10 +2,11 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 )
 
 func RespondJSON(w http.ResponseWriter, payload interface{}) {
@@ -41,6 +42,12 @@ func PrettifyLocation(raw string) string {
 	return strings.Join(segments, ", ")
 }
 
+// LocationCountry retourne le pays d'un lieu brut de l'API (ex: "new_zealand" -> "New Zealand")
+func LocationCountry(raw string) string {
+	segments := strings.Split(PrettifyLocation(raw), ", ")
+	return segments[len(segments)-1]
+}
+
 func MathOrDefault(length int, fn func() int) int {
 	if length == 0 {
 		return 0
@@ -48,18 +55,65 @@ func MathOrDefault(length int, fn func() int) int {
 	return fn()
 }
 
-func ParseYear(value string) int {
+// DateLayout est le format des dates de l'API ("dd-mm-yyyy")
+const DateLayout = "02-01-2006"
+
+// ParseDate lit une date de l'API ; le préfixe "*" éventuel est ignoré
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "*")
 	if value == "" {
-		return 0
+		return time.Time{}, false
 	}
-	parts := strings.Split(value, "-")
-	if len(parts) != 3 {
-		return 0
-	}
-	year, err := strconv.Atoi(parts[2])
+	t, err := time.Parse(DateLayout, value)
 	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func ParseYear(value string) int {
+	t, ok := ParseDate(value)
+	if !ok {
 		return 0
 	}
-	return year
+	return t.Year()
+}
+
+// FormatAge formate l'ancienneté des données pour l'affichage
+func FormatAge(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return "à l'instant"
+	case age < time.Hour:
+		return fmt.Sprintf("il y a %d min", int(age.Minutes()))
+	case age < 24*time.Hour:
+		return fmt.Sprintf("il y a %d h", int(age.Hours()))
+	default:
+		return fmt.Sprintf("il y a %d j", int(age.Hours()/24))
+	}
 }
 
+// accentFolds ramène les lettres accentuées latines à leur forme de base
+var accentFolds = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ą", "a",
+	"ç", "c", "ć", "c", "č", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ę", "e", "ě", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ł", "l", "ñ", "n", "ń", "n", "ň", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o",
+	"ř", "r", "ś", "s", "š", "s", "ß", "ss",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ů", "u",
+	"ý", "y", "ÿ", "y", "ź", "z", "ż", "z", "ž", "z",
+	"æ", "ae", "œ", "oe",
+)
+
+// searchSeparators remplace les séparateurs par des espaces
+var searchSeparators = strings.NewReplacer("-", " ", "_", " ", ",", " ", "*", "")
+
+// FoldText normalise un texte pour la recherche : minuscules, sans accents,
+// séparateurs (-, _, ,) remplacés par des espaces.
+func FoldText(value string) string {
+	folded := accentFolds.Replace(strings.ToLower(value))
+	folded = searchSeparators.Replace(folded)
+	return strings.Join(strings.Fields(folded), " ")
+}
@@ -1,6 +1,8 @@
 const state = {
   raw: window.__ARTISTS || [],
   displayed: [],
+  filteredOnLoad: false,
+  chips: [],
 };
 
 document.addEventListener("DOMContentLoaded", () => {
@@ -8,6 +10,7 @@ document.addEventListener("DOMContentLoaded", () => {
   const filterForm = document.getElementById("filter-form");
   const suggestions = document.getElementById("suggestions");
   const searchInput = document.getElementById("search-input");
+  const chipsBox = document.getElementById("query-chips");
   const total = document.getElementById("total-artists");
   const filtersOverlay = document.getElementById("filters-overlay");
   const openFilterButtons = document.querySelectorAll("[data-open-filters]");
@@ -68,7 +71,7 @@ document.addEventListener("DOMContentLoaded", () => {
 
       card.innerHTML = `
         <div class="artist-card__visual">
-          <img src="${artist.name === "Mamonas Assassinas" ? "/assets/pictures/cover.png" : artist.image}" alt="${artist.name}">
+          <img src="/img/artist/${artist.id}?size=card" alt="${artist.name}">
           <button class="favorite-btn ${favActive}" data-id="${artist.id}" title="${isFavorite ? 'Retirer des favoris' : 'Ajouter aux favoris'}">
             <i class="${favClass}"></i>
           </button>
@@ -166,11 +169,28 @@ document.addEventListener("DOMContentLoaded", () => {
     });
     filterForm.classList.add("is-loading");
     try {
-      const response = await fetch(`/api/filter?${params.toString()}`);
+      // facets=1 : nombre d'artistes restants pour chaque lieu et pays
+      const response = await fetch(`/api/filter?${params.toString()}&facets=1`);
+      if (response.status === 401) {
+        window.location.href = "/login";
+        return;
+      }
+      if (response.status === 400 || response.status === 404) {
+        // Paramètre refusé ou ville du profil introuvable : afficher le message du serveur
+        const { error } = await response.json();
+        grid.innerHTML = `<div class="empty"><p>${error}</p></div>`;
+        toggleFilters(false);
+        return;
+      }
       if (!response.ok) throw new Error(`HTTP ${response.status}`);
       const data = await response.json();
-      renderCards(data);
+      // Avec un filtre géographique ou des facettes, les artistes sont dans data.artists
+      renderCards(Array.isArray(data) ? data : data.artists);
+      updateFacetCounts(data.facets);
       toggleFilters(false);
+      // Garder les filtres dans l'URL pour pouvoir partager ou recharger la vue
+      const query = params.toString();
+      history.replaceState(null, "", query ? `/?${query}` : "/");
     } catch (err) {
       console.error(err);
       grid.innerHTML = `<div class="empty">
@@ -182,10 +202,46 @@ document.addEventListener("DOMContentLoaded", () => {
     }
   };
 
+  const updateFacetCounts = (facets) => {
+    if (!facets) return;
+    [["location", facets.locations], ["country", facets.countries]].forEach(([name, counts]) => {
+      const byKey = new Map((counts || []).map((f) => [f.key, f.count]));
+      filterForm.querySelectorAll(`input[name="${name}"]`).forEach((input) => {
+        const badge = input.closest("label")?.querySelector("[data-facet-count]");
+        if (!badge) return;
+        const count = byKey.get(input.value) ?? 0;
+        badge.textContent = `(${count})`;
+        input.closest("label").classList.toggle("is-empty", count === 0 && !input.checked);
+      });
+    });
+  };
+
   const resetFilters = () => {
+    // La grille initiale était déjà filtrée côté serveur : recharger la liste complète
+    if (state.filteredOnLoad) {
+      window.location.href = "/";
+      return;
+    }
+    history.replaceState(null, "", "/");
     renderCards(state.raw);
   };
 
+  // Pré-remplir le formulaire avec les filtres de l'URL (rendu serveur déjà filtré)
+  const restoreFilters = () => {
+    if (!filterForm) return;
+    const params = new URLSearchParams(window.location.search);
+    state.filteredOnLoad = [...params.keys()].length > 0;
+    params.forEach((value, key) => {
+      filterForm.querySelectorAll(`[name="${key}"]`).forEach((input) => {
+        if (input.type === "checkbox") {
+          if (input.value === value) input.checked = true;
+        } else {
+          input.value = value;
+        }
+      });
+    });
+  };
+
   const scrollToCard = (id) => {
     const card = grid.querySelector(`[data-id="${id}"]`);
     if (!card) return;
@@ -296,18 +352,100 @@ document.addEventListener("DOMContentLoaded", () => {
       suggestions.classList.remove("is-open");
       return;
     }
-    const items = buildSuggestions(query);
-    renderSuggestions(items);
+    // Ignorer les réponses arrivées après une nouvelle saisie
+    const isCurrent = () => event.target.value.trim() === query;
+    fetchSuggestions(query)
+      .then((items) => isCurrent() && renderSuggestions(items))
+      .catch(() => isCurrent() && renderSuggestions(buildSuggestions(query)));
+  };
+
+  // Recherche serveur (tolérante aux fautes et aux accents), recherche locale en secours
+  const fetchSuggestions = async (query) => {
+    const response = await fetch(`/api/search?q=${encodeURIComponent(query)}&limit=3`);
+    if (!response.ok) {
+      throw new Error(`Erreur HTTP: ${response.status}`);
+    }
+    const data = await response.json();
+    const items = [];
+    (data.groups || []).forEach((group) => {
+      group.results.forEach((result) => {
+        items.push({ label: result.value, type: group.label, meta: result.label, id: result.artistId });
+      });
+    });
+    return items.slice(0, 8);
   };
 
   const debouncedSearch = debounce(handleSearch, 150);
 
-  const handleSearchEnter = (event) => {
+  // Puces des expressions comprises dans une requête en langage naturel (/api/search/parse)
+  const renderChips = () => {
+    if (!chipsBox) return;
+    chipsBox.innerHTML = "";
+    state.chips.forEach((chip, index) => {
+      const button = document.createElement("button");
+      button.type = "button";
+      button.className = "query-chip";
+      button.title = `« ${chip.text} » — retirer`;
+      button.textContent = `${chip.label} ✕`;
+      button.addEventListener("click", () => {
+        state.chips.splice(index, 1);
+        applyChips();
+      });
+      chipsBox.appendChild(button);
+    });
+  };
+
+  const applyChips = async () => {
+    renderChips();
+    if (!state.chips.length) {
+      renderCards(state.raw);
+      return;
+    }
+    const params = new URLSearchParams();
+    state.chips.forEach((chip) => {
+      Object.entries(chip.params).forEach(([key, values]) => {
+        if (key !== "country" && key !== "location") params.delete(key);
+        values.forEach((value) => params.append(key, value));
+      });
+    });
+    try {
+      const response = await fetch(`/api/filter?${params.toString()}`);
+      if (!response.ok) throw new Error(`HTTP ${response.status}`);
+      const data = await response.json();
+      renderCards(Array.isArray(data) ? data : data.artists);
+    } catch (err) {
+      console.error(err);
+    }
+  };
+
+  const parseQuery = async (query) => {
+    const response = await fetch(`/api/search/parse?q=${encodeURIComponent(query)}`);
+    if (!response.ok) throw new Error(`HTTP ${response.status}`);
+    return response.json();
+  };
+
+  const handleSearchEnter = async (event) => {
     if (event.key !== "Enter" || !suggestions) return;
+    event.preventDefault();
+    const query = searchInput.value.trim();
+    if (query) {
+      try {
+        const parsed = await parseQuery(query);
+        if (parsed.phrases.length) {
+          state.chips = parsed.phrases;
+          searchInput.value = parsed.remainder;
+          suggestions.innerHTML = "";
+          suggestions.classList.remove("is-open");
+          applyChips();
+          return;
+        }
+      } catch (err) {
+        console.error(err);
+      }
+    }
     const firstSuggestion = suggestions.querySelector("li");
     if (firstSuggestion) {
       firstSuggestion.click();
-      event.preventDefault();
     }
   };
 
@@ -340,6 +478,7 @@ document.addEventListener("DOMContentLoaded", () => {
     });
   }
 
+  restoreFilters();
   renderCards(state.raw);
 });
 
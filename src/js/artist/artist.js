@@ -112,90 +112,91 @@ function initArtistMap() {
     
     const markerGroup = L.layerGroup().addTo(map);
     
-    if (Array.isArray(artist.concerts)) {
-        artist.concerts.forEach((concert) => {
-            let lat, lng;
-            if (concert.coordinates) {
-                if (concert.coordinates.lat !== undefined && concert.coordinates.lng !== undefined) {
-                    lat = parseFloat(concert.coordinates.lat);
-                    lng = parseFloat(concert.coordinates.lng);
-                }
-                else if (concert.coordinates.latitude !== undefined && concert.coordinates.longitude !== undefined) {
-                    lat = parseFloat(concert.coordinates.latitude);
-                    lng = parseFloat(concert.coordinates.longitude);
-                }
+    function addConcertMarker(concert) {
+        let lat, lng;
+        if (concert.coordinates) {
+            if (concert.coordinates.lat !== undefined && concert.coordinates.lng !== undefined) {
+                lat = parseFloat(concert.coordinates.lat);
+                lng = parseFloat(concert.coordinates.lng);
             }
-            
-            if (lat === undefined || lng === undefined || isNaN(lat) || isNaN(lng)) {
-                console.warn(`Coordonnées manquantes ou invalides pour: ${concert.displayLocation || 'Lieu inconnu'}`, concert.coordinates);
-                return;
+            else if (concert.coordinates.latitude !== undefined && concert.coordinates.longitude !== undefined) {
+                lat = parseFloat(concert.coordinates.latitude);
+                lng = parseFloat(concert.coordinates.longitude);
             }
-            
-            if (lat < -90 || lat > 90 || lng < -180 || lng > 180) {
-                console.warn(`Coordonnées hors limites pour: ${concert.displayLocation || 'Lieu inconnu'}`, lat, lng);
-                return;
+        }
+        
+        if (lat === undefined || lng === undefined || isNaN(lat) || isNaN(lng)) {
+            if (concert.coordinates) {
+                console.warn(`Coordonnées invalides pour: ${concert.displayLocation || 'Lieu inconnu'}`, concert.coordinates);
             }
-            
-            const markerIcon = L.divIcon({
-                className: `custom-marker upcoming`,
-                html: `<div class="marker-pin">
-                    <svg viewBox="0 0 24 36" fill="none" xmlns="http://www.w3.org/2000/svg">
-                        <path d="M12 0C5.373 0 0 5.373 0 12c0 9 12 24 12 24s12-15 12-24c0-6.627-5.373-12-12-12z" fill="currentColor"/>
-                        <circle cx="12" cy="12" r="5" fill="white"/>
-                    </svg>
-                </div>`,
-                iconSize: [28, 36],
-                iconAnchor: [14, 36],
-                popupAnchor: [0, -36]
-            });
-            
-            const marker = L.marker([lat, lng], {
-                icon: markerIcon
+            return false;
+        }
+        
+        if (lat < -90 || lat > 90 || lng < -180 || lng > 180) {
+            console.warn(`Coordonnées hors limites pour: ${concert.displayLocation || 'Lieu inconnu'}`, lat, lng);
+            return false;
+        }
+        
+        const markerIcon = L.divIcon({
+            className: `custom-marker upcoming`,
+            html: `<div class="marker-pin">
+                <svg viewBox="0 0 24 36" fill="none" xmlns="http://www.w3.org/2000/svg">
+                    <path d="M12 0C5.373 0 0 5.373 0 12c0 9 12 24 12 24s12-15 12-24c0-6.627-5.373-12-12-12z" fill="currentColor"/>
+                    <circle cx="12" cy="12" r="5" fill="white"/>
+                </svg>
+            </div>`,
+            iconSize: [28, 36],
+            iconAnchor: [14, 36],
+            popupAnchor: [0, -36]
+        });
+        
+        const marker = L.marker([lat, lng], {
+            icon: markerIcon
+        });
+        
+        marker.bindPopup(createPopupContent(concert), {
+            maxWidth: 300,
+            minWidth: 200,
+            className: 'custom-popup',
+            closeButton: true,
+            autoClose: false,
+            closeOnClick: false
+        });
+        
+        marker.addTo(markerGroup);
+        markers.push(marker);
+        bounds.push([lat, lng]);
+        hasValidLocations = true;
+        
+        marker.getElement()._marker = marker;
+        
+        const element = marker.getElement();
+        if (element) {
+            element.addEventListener('mouseover', () => {
+                element.style.transform = 'scale(1.3) rotate(-45deg)';
+                element.style.transition = 'transform 0.2s ease';
+                element.style.zIndex = '1000';
             });
             
-            marker.bindPopup(createPopupContent(concert), {
-                maxWidth: 300,
-                minWidth: 200,
-                className: 'custom-popup',
-                closeButton: true,
-                autoClose: false,
-                closeOnClick: false
+            element.addEventListener('mouseout', () => {
+                element.style.transform = 'scale(1) rotate(-45deg)';
+                element.style.zIndex = '';
             });
             
-            marker.addTo(markerGroup);
-            markers.push(marker);
-            bounds.push([lat, lng]);
-            hasValidLocations = true;
-            
-            marker.getElement()._marker = marker;
-            
-            const element = marker.getElement();
-            if (element) {
-                element.addEventListener('mouseover', () => {
-                    element.style.transform = 'scale(1.3) rotate(-45deg)';
-                    element.style.transition = 'transform 0.2s ease';
-                    element.style.zIndex = '1000';
-                });
-                
-                element.addEventListener('mouseout', () => {
-                    element.style.transform = 'scale(1) rotate(-45deg)';
-                    element.style.zIndex = '';
-                });
-                
-                element.addEventListener('click', () => {
-                    element.style.transform = 'scale(0.8) rotate(-45deg)';
+            element.addEventListener('click', () => {
+                element.style.transform = 'scale(0.8) rotate(-45deg)';
+                setTimeout(() => {
+                    element.style.transform = 'scale(1.2) rotate(-45deg)';
                     setTimeout(() => {
-                        element.style.transform = 'scale(1.2) rotate(-45deg)';
-                        setTimeout(() => {
-                            element.style.transform = 'scale(1) rotate(-45deg)';
-                        }, 100);
+                        element.style.transform = 'scale(1) rotate(-45deg)';
                     }, 100);
-                });
-            }
-        });
+                }, 100);
+            });
+        }
+        return true;
     }
     
-    if (hasValidLocations && bounds.length > 0) {
+    function fitMarkers() {
         if (bounds.length === 1) {
             map.setView(bounds[0], 13);
         } else {
@@ -204,11 +205,64 @@ function initArtistMap() {
                 maxZoom: 15
             });
         }
+    }
+    
+    // Concerts rendus sans coordonnées : géocodés à la demande par /api/artist/{id}/coordinates
+    const pendingConcerts = Array.isArray(artist.concerts)
+        ? artist.concerts.filter((concert) => !concert.coordinates)
+        : [];
+    
+    function loadPendingCoordinates(attempt) {
+        fetch(`/api/artist/${artist.id}/coordinates`)
+            .then((response) => {
+                if (!response.ok) throw new Error(`HTTP ${response.status}`);
+                const retryAfter = parseInt(response.headers.get('Retry-After'), 10);
+                return response.json().then((data) => ({ data, retryAfter }));
+            })
+            .then(({ data, retryAfter }) => {
+                let added = 0;
+                for (let i = pendingConcerts.length - 1; i >= 0; i--) {
+                    const concert = pendingConcerts[i];
+                    const coords = data.coordinates && data.coordinates[concert.location];
+                    if (!coords) continue;
+                    concert.coordinates = coords;
+                    pendingConcerts.splice(i, 1);
+                    if (addConcertMarker(concert)) added++;
+                }
+                if (added > 0) fitMarkers();
+                
+                if (data.pending && data.pending.length > 0 && attempt < 5) {
+                    const delay = (retryAfter > 0 ? retryAfter : 3) * 1000;
+                    setTimeout(() => loadPendingCoordinates(attempt + 1), delay);
+                } else if (!hasValidLocations) {
+                    showMapError(target, "Aucune localisation valide n'a été trouvée pour cet artiste.");
+                }
+            })
+            .catch((error) => {
+                console.error('Erreur lors du géocodage des concerts:', error);
+                if (!hasValidLocations) {
+                    showMapError(target, "Impossible de localiser les concerts de cet artiste.");
+                }
+            });
+    }
+    
+    if (Array.isArray(artist.concerts)) {
+        artist.concerts.forEach(addConcertMarker);
+    }
+    
+    if (hasValidLocations && bounds.length > 0) {
+        fitMarkers();
+    } else if (pendingConcerts.length > 0) {
+        map.setView([20, 0], 2);
     } else {
         showMapError(target, "Aucune localisation valide n'a été trouvée pour cet artiste.");
         return;
     }
     
+    if (pendingConcerts.length > 0) {
+        loadPendingCoordinates(0);
+    }
+    
     let resizeTimer;
     window.addEventListener('resize', () => {
         clearTimeout(resizeTimer);
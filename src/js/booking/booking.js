@@ -60,7 +60,7 @@ function openBookingModal(concertData) {
     }
     
     const artistName = concertData.artistName || (window.__ARTIST ? window.__ARTIST.name : '');
-    const artistImage = concertData.artistImage || (window.__ARTIST ? window.__ARTIST.image : '');
+    const artistImage = concertData.artistImage || (window.__ARTIST ? `/img/artist/${window.__ARTIST.id}?size=thumb` : '');
     
     document.getElementById('booking-artist-name').textContent = artistName;
     if (artistImage) {
@@ -123,7 +123,7 @@ function createBookingModal() {
             
             <div class="booking-header">
                 <div class="booking-artist-info">
-                    <img src="${window.__ARTIST ? window.__ARTIST.image : ''}" alt="${window.__ARTIST ? window.__ARTIST.name : ''}" class="booking-artist-image" onerror="this.style.display='none'">
+                    <img src="${window.__ARTIST ? `/img/artist/${window.__ARTIST.id}?size=thumb` : ''}" alt="${window.__ARTIST ? window.__ARTIST.name : ''}" class="booking-artist-image" onerror="this.style.display='none'">
                     <div>
                         <h2 id="booking-artist-name">${window.__ARTIST ? window.__ARTIST.name : ''}</h2>
                         <div class="booking-location-info">
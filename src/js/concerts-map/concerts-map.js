@@ -89,7 +89,7 @@ function initConcertsMap() {
         const artistData = encodeURIComponent(JSON.stringify({
             id: artist.id,
             name: artist.name,
-            image: artist.image,
+            image: `/img/artist/${artist.id}?size=thumb`,
             members: artist.members || [],
             creationDate: artist.creationDate,
             firstAlbum: artist.firstAlbum,
@@ -113,7 +113,7 @@ function initConcertsMap() {
         return `
             <div class="concert-popup enhanced">
                 <div class="popup-header">
-                    <img src="${artist.image}" alt="${artist.name}" class="popup-artist-image" onerror="this.src='data:image/svg+xml,%3Csvg xmlns=%22http://www.w3.org/2000/svg%22 viewBox=%220 0 100 100%22%3E%3Crect fill=%22%23a855f7%22 width=%22100%22 height=%22100%22/%3E%3Ctext x=%2250%22 y=%2250%22 text-anchor=%22middle%22 dy=%22.3em%22 fill=%22white%22 font-size=%2220%22%3E${artist.name.charAt(0)}%3C/text%3E%3C/svg%3E'">
+                    <img src="/img/artist/${artist.id}?size=thumb" alt="${artist.name}" class="popup-artist-image" onerror="this.src='data:image/svg+xml,%3Csvg xmlns=%22http://www.w3.org/2000/svg%22 viewBox=%220 0 100 100%22%3E%3Crect fill=%22%23a855f7%22 width=%22100%22 height=%22100%22/%3E%3Ctext x=%2250%22 y=%2250%22 text-anchor=%22middle%22 dy=%22.3em%22 fill=%22white%22 font-size=%2220%22%3E${artist.name.charAt(0)}%3C/text%3E%3C/svg%3E'">
                     <div class="popup-artist-info">
                         <h4>${artist.name}</h4>
                         <p class="popup-location"><i class="fas fa-map-marker-alt"></i> <strong>${concert.displayLocation || 'Lieu inconnu'}</strong></p>
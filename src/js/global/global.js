@@ -556,7 +556,7 @@ window.openArtistDetailsFromMap = function(artistDataEncoded) {
             </button>
             
             <div class="artist-detail-header">
-                <img src="${artist.image}" alt="${artist.name}" class="artist-detail-img" onerror="this.src='data:image/svg+xml,%3Csvg xmlns=%22http://www.w3.org/2000/svg%22 viewBox=%220 0 100 100%22%3E%3Crect fill=%22%23a855f7%22 width=%22100%22 height=%22100%22/%3E%3Ctext x=%2250%22 y=%2250%22 text-anchor=%22middle%22 dy=%22.3em%22 fill=%22white%22 font-size=%2224%22%3E${artist.name.charAt(0)}%3C/text%3E%3C/svg%3E'">
+                <img src="/img/artist/${artist.id}?size=card" alt="${artist.name}" class="artist-detail-img" onerror="this.src='data:image/svg+xml,%3Csvg xmlns=%22http://www.w3.org/2000/svg%22 viewBox=%220 0 100 100%22%3E%3Crect fill=%22%23a855f7%22 width=%22100%22 height=%22100%22/%3E%3Ctext x=%2250%22 y=%2250%22 text-anchor=%22middle%22 dy=%22.3em%22 fill=%22white%22 font-size=%2224%22%3E${artist.name.charAt(0)}%3C/text%3E%3C/svg%3E'">
                 <div class="artist-detail-info">
                     <h2>${artist.name}</h2>
                     <div class="artist-meta">
@@ -629,7 +629,7 @@ window.buyTicketFromMap = function(artistDataEncoded, concertDataEncoded) {
         const concertData = {
             artistId: artist.id,
             artistName: artist.name,
-            artistImage: artist.image,
+            artistImage: `/img/artist/${artist.id}?size=thumb`,
             location: concert.displayLocation || concert.location || 'Lieu inconnu',
             city: '',
             country: '',
@@ -861,7 +861,8 @@ async function handleRefreshClick(event) {
       throw new Error(`Erreur HTTP: ${response.status}`);
     }
     
-    const data = await response.json();
+    const job = await response.json();
+    await waitForRefreshJob(job.id, button);
     
     button.classList.add("is-success");
     button.innerHTML = `
@@ -895,6 +896,33 @@ async function handleRefreshClick(event) {
   }
 }
 
+// Suit l'avancement d'un job de rafraîchissement jusqu'à sa fin
+async function waitForRefreshJob(jobId, button) {
+  while (true) {
+    await new Promise((resolve) => setTimeout(resolve, 1000));
+
+    const response = await fetch(`/api/refresh/${jobId}`, {
+      headers: { 'X-Requested-With': 'XMLHttpRequest' }
+    });
+    if (!response.ok) {
+      throw new Error(`Erreur HTTP: ${response.status}`);
+    }
+
+    const job = await response.json();
+    if (job.status === "done") {
+      return job;
+    }
+    if (job.status === "failed") {
+      throw new Error(job.error || "Rafraîchissement échoué");
+    }
+
+    const label = button.querySelector("span:last-child");
+    if (label && job.total > 0) {
+      label.textContent = `Mise à jour ${job.done}/${job.total}...`;
+    }
+  }
+}
+
 function resetButton(button, originalContent) {
   button.disabled = false;
   button.classList.remove("is-loading", "is-success", "is-error");
@@ -94,7 +94,7 @@ function initGlobalMap() {
         const dates = concert.dates || [];
         const artistName = artist.name;
         const artistId = artist.id;
-        const artistImage = artist.image;
+        const artistImage = `/img/artist/${artist.id}?size=thumb`;
         const members = artist.members || [];
         
         // Debug: vérifier les données de l'artiste
@@ -19,10 +19,17 @@ const MEMBER_INFO = {
         ];
         
         const roleIndex = memberName.length % roles.length;
-        const role = roles[roleIndex];
+        let role = roles[roleIndex];
         const selectedInstruments = [];
         
-        if (role.name === 'Guitariste') {
+        // Rôle et instruments réels fournis par l'overlay, s'ils existent
+        const details = ((window.__ARTIST && window.__ARTIST.memberDetails) || [])
+            .find(m => m.name === memberName && m.role);
+        if (details) {
+            const known = roles.find(r => r.name.toLowerCase() === details.role.toLowerCase());
+            role = known || { name: details.role, icon: 'fas fa-music', description: details.role };
+            selectedInstruments.push(...(details.instruments || []));
+        } else if (role.name === 'Guitariste') {
             selectedInstruments.push('Guitare électrique', 'Guitare acoustique');
         } else if (role.name === 'Batteur') {
             selectedInstruments.push('Batterie', 'Percussions');